@@ -0,0 +1,33 @@
+package def
+
+import (
+	"fmt"
+	"strings"
+)
+
+// The api attribute on types, members, params, commands, enums and require blocks, and the supported attribute on
+// extensions, hold a comma-separated list of API names like "vulkan,vulkansc". An element without the attribute
+// applies to every API. Note that a substring test is not sufficient here, since "vulkan" is a prefix of "vulkansc".
+
+// ApiPredicate returns an XPath boolean expression, suitable for use inside [], which is true when the context node
+// has no attr attribute or when api is one of the names in its list.
+func ApiPredicate(attr, api string) string {
+	return fmt.Sprintf("(not(@%[1]s) or contains(concat(',', translate(@%[1]s, ' ', ''), ','), ',%[2]s,'))", attr, api)
+}
+
+// ApiListContains reports whether the comma-separated list of API names includes api. An empty list does not
+// include any API; use ApiListMatches when a missing attribute should match everything.
+func ApiListContains(list, api string) bool {
+	for _, s := range strings.Split(list, ",") {
+		if strings.TrimSpace(s) == api {
+			return true
+		}
+	}
+	return false
+}
+
+// ApiListMatches is the Go equivalent of ApiPredicate: it reports whether an element with the given api (or
+// supported) attribute value should be included when generating for api.
+func ApiListMatches(list, api string) bool {
+	return list == "" || ApiListContains(list, api)
+}
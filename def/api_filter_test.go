@@ -0,0 +1,75 @@
+package def
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/antchfx/xmlquery"
+)
+
+var apiListCases = []struct {
+	list, api         string
+	contains, matches bool
+}{
+	{"vulkan", "vulkan", true, true},
+	{"vulkan", "vulkansc", false, false},
+	{"vulkansc", "vulkan", false, false},
+	{"vulkansc", "vulkansc", true, true},
+	{"vulkan,vulkansc", "vulkan", true, true},
+	{"vulkan,vulkansc", "vulkansc", true, true},
+	{"vulkansc,vulkan", "vulkan", true, true},
+	{"vulkan, vulkansc", "vulkansc", true, true},
+	{" vulkan ,vulkansc", "vulkan", true, true},
+	{"disabled", "vulkan", false, false},
+	{"", "vulkan", false, true},
+	{"", "vulkansc", false, true},
+}
+
+func TestApiListContains(t *testing.T) {
+	for _, c := range apiListCases {
+		if got := ApiListContains(c.list, c.api); got != c.contains {
+			t.Errorf("ApiListContains(%q, %q) = %v, want %v", c.list, c.api, got, c.contains)
+		}
+	}
+}
+
+func TestApiListMatches(t *testing.T) {
+	for _, c := range apiListCases {
+		if got := ApiListMatches(c.list, c.api); got != c.matches {
+			t.Errorf("ApiListMatches(%q, %q) = %v, want %v", c.list, c.api, got, c.matches)
+		}
+	}
+}
+
+// TestApiPredicate checks that the XPath predicate selects the same elements as ApiListMatches
+func TestApiPredicate(t *testing.T) {
+	sb := &strings.Builder{}
+	sb.WriteString("<registry>")
+	for _, c := range apiListCases {
+		if c.list == "" {
+			sb.WriteString("<type/>")
+		} else {
+			sb.WriteString(`<type api="` + c.list + `"/>`)
+		}
+	}
+	sb.WriteString("</registry>")
+
+	doc, err := xmlquery.Parse(strings.NewReader(sb.String()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, api := range []string{"vulkan", "vulkansc"} {
+		selected := make(map[*xmlquery.Node]bool)
+		for _, n := range xmlquery.Find(doc, "//type["+ApiPredicate("api", api)+"]") {
+			selected[n] = true
+		}
+
+		for _, n := range xmlquery.Find(doc, "//type") {
+			list := n.SelectAttr("api")
+			if want := ApiListMatches(list, api); selected[n] != want {
+				t.Errorf("ApiPredicate(\"api\", %q) selected element with api=%q: %v, want %v", api, list, selected[n], want)
+			}
+		}
+	}
+}
@@ -92,7 +92,7 @@ func (t *baseType) PrintTranslateToInternal(w io.Writer, inputVar, outputVar str
 }
 
 func ReadBaseTypesFromXML(doc *xmlquery.Node, tr TypeRegistry, _ ValueRegistry, api string) {
-	queryString := fmt.Sprintf("//types/type[@category='basetype' and (@api='%s' or not(@api))]", api)
+	queryString := fmt.Sprintf("//types/type[@category='basetype' and %s]", ApiPredicate("api", api))
 
 	for _, node := range xmlquery.Find(doc, queryString) {
 		newType := NewBaseTypeFromXML(node)
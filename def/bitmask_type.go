@@ -50,7 +50,7 @@ func (t *bitmaskType) PrintPublicDeclaration(w io.Writer) {
 }
 
 func ReadBitmaskTypesFromXML(doc *xmlquery.Node, tr TypeRegistry, vr ValueRegistry, api string) {
-	queryString := fmt.Sprintf("//types/type[@category='bitmask' and (@api='%s' or not(@api))]", api)
+	queryString := fmt.Sprintf("//types/type[@category='bitmask' and %s]", ApiPredicate("api", api))
 
 	for _, node := range xmlquery.Find(doc, queryString) {
 		newType := NewBitmaskTypeFromXML(node)
@@ -65,10 +65,20 @@ func ReadBitmaskTypesFromXML(doc *xmlquery.Node, tr TypeRegistry, vr ValueRegist
 			// newType.resolvedRequiresType = tr[newType.valuesTypeName]
 
 			if r, ok := (tr[newType.valuesTypeName]).(*enumType); ok {
+				if r.isBitmaskType && (r.bitwidth == 64) != (newType.underlyingTypeName == "VkFlags64") {
+					logrus.WithField("registry name", newType.registryName).
+						WithField("underlying type", newType.underlyingTypeName).
+						WithField("requires", newType.valuesTypeName).
+						WithField("bitwidth", r.bitwidth).
+						Warn("Bitmask's underlying type does not match the bit width of its values")
+				}
 				// Force set the enum's underlying type to be this bitmaskType
 				r.underlyingTypeName = newType.registryName
 			} else {
-				panic("Bitmask requires non-enum as underlying type!")
+				logrus.WithField("registry name", newType.registryName).
+					WithField("requires", newType.valuesTypeName).
+					Error("Bitmask requires a type that is not an enum; bitmask values will not be available")
+				newType.valuesTypeName = ""
 			}
 		}
 
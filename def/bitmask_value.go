@@ -3,8 +3,10 @@ package def
 import (
 	"fmt"
 	"io"
+	"strconv"
 
 	"github.com/antchfx/xmlquery"
+	"github.com/sirupsen/logrus"
 )
 
 type bitmaskValue struct {
@@ -25,7 +27,35 @@ func (v *bitmaskValue) ValueString() string {
 	}
 }
 
+// checkBitpos reports whether the value's bit position, if it has one, fits in a bitmask of bitwidth bits. If not, the
+// error is logged.
+func (v *bitmaskValue) checkBitpos(bitwidth int) bool {
+	if v.bitposString == "" {
+		return true
+	}
+	if pos, err := strconv.Atoi(v.bitposString); err == nil && pos >= 0 && pos < bitwidth {
+		return true
+	}
+	logrus.WithField("registry name", v.registryName).
+		WithField("bitpos", v.bitposString).
+		WithField("bitwidth", bitwidth).
+		Error("Bit position does not fit in the bitmask; skipping value")
+	return false
+}
+
+// BitposFits reports whether the bit position of vd fits in td, the enum of bitmask bits that it extends. If not, the
+// error is logged and the value should be skipped.
+func BitposFits(td TypeDefiner, vd ValueDefiner) bool {
+	et, ok := td.(*enumType)
+	bv, isBitmaskValue := vd.(*bitmaskValue)
+	if !ok || !isBitmaskValue || !et.isBitmaskType {
+		return true
+	}
+	return bv.checkBitpos(et.bitwidth)
+}
+
 func (v *bitmaskValue) PrintPublicDeclaration(w io.Writer) {
+	v.PrintDeprecationNote(w)
 	fmt.Fprintf(w, "%s %s = %s\n", v.PublicName(), v.resolvedType.PublicName(), v.ValueString())
 }
 
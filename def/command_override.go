@@ -0,0 +1,150 @@
+package def
+
+import (
+	log "github.com/sirupsen/logrus"
+	"github.com/tidwall/gjson"
+)
+
+// paramRole forces the classification of a command parameter in commandType.PrintPublicDeclaration, for the cases
+// where the rules derived from vk.xml produce the wrong binding.
+type paramRole string
+
+const (
+	roleAuto paramRole = ""
+	// roleInputSlice is a const pointer passed by the caller as a slice
+	roleInputSlice paramRole = "inputSlice"
+	// roleOutputSlice is an array allocated by the binding, with a length given by "len" or a length parameter, and
+	// returned to the caller
+	roleOutputSlice paramRole = "outputSlice"
+	// roleDoubleCall is an array returned through the two-call idiom, where the length is a pointer parameter
+	roleDoubleCall paramRole = "doubleCall"
+	// roleUserAllocated is a slice allocated by the caller and written to by Vulkan
+	roleUserAllocated paramRole = "userAllocated"
+)
+
+// paramOverride holds the exceptions.json settings for one parameter of a command:
+//
+//	"command": { "vkCommandName": { "params": { "pParamName": {
+//	  "role": "inputSlice" | "outputSlice" | "doubleCall" | "userAllocated",
+//	  "publicName": "name",
+//	  "optional": true | false,
+//	  "len": "Go expression"
+//	} } } }
+//
+// Param keys are registry names. "len" is a Go expression, which may refer to the public names of other params. For
+// an output slice it sets the slice length; for a non-pointer param it replaces the param in the Go function signature
+// and the expression is passed to Vulkan instead. It is not allowed on input slices, which take their length from the
+// slice.
+type paramOverride struct {
+	role       paramRole
+	publicName string
+	optional   string // "true", "false", or empty to keep the registry's optional attribute
+	lenExpr    string
+}
+
+func newParamOverrideFromJSON(commandName, paramName string, json gjson.Result) *paramOverride {
+	rval := paramOverride{
+		role:       paramRole(json.Get("role").String()),
+		publicName: json.Get("publicName").String(),
+		lenExpr:    json.Get("len").String(),
+	}
+
+	switch rval.role {
+	case roleAuto, roleInputSlice, roleOutputSlice, roleDoubleCall, roleUserAllocated:
+	default:
+		log.WithField("command", commandName).
+			WithField("param", paramName).
+			WithField("role", rval.role).
+			Error("unknown param role in exceptions.json, ignoring")
+		rval.role = roleAuto
+	}
+
+	if opt := json.Get("optional"); opt.Exists() {
+		if opt.Bool() {
+			rval.optional = "true"
+		} else {
+			rval.optional = "false"
+		}
+	}
+
+	return &rval
+}
+
+// applyParamOverrides copies the exceptions.json overrides onto the command's parameters, before they are resolved
+func (t *commandType) applyParamOverrides() {
+	for name, o := range t.paramOverrides {
+		p := t.findParam(name)
+		if p == nil {
+			log.WithField("command", t.registryName).
+				WithField("param", name).
+				Warn("exceptions.json overrides a param that the command does not have")
+			continue
+		}
+
+		p.role = o.role
+		p.lenExpr = o.lenExpr
+		p.overridePublicName = o.publicName
+		if o.optional != "" {
+			p.optionalParamString = o.optional
+		}
+	}
+}
+
+// checkRole validates a forced role once the length parameter is known, and adjusts the param so that
+// PrintPublicDeclaration takes the matching branch. An invalid role is logged and dropped, as is a len expression on
+// an input slice, whose length is always the length of the slice passed by the caller.
+func (p *commandParam) checkRole() {
+	if p.role != roleAuto {
+		reason := ""
+		switch {
+		case p.pointerLevel == 0:
+			reason = "param is not a pointer"
+		case p.role == roleDoubleCall && (p.lenMemberParam == nil || p.lenMemberParam.pointerLevel == 0):
+			reason = "doubleCall requires a len attribute naming a pointer param"
+		case p.role == roleOutputSlice && p.lenMemberParam == nil && p.lenExpr == "":
+			reason = "outputSlice requires a length param or a len expression"
+		case (p.role == roleOutputSlice || p.role == roleUserAllocated) && p.lenMemberParam != nil && p.lenMemberParam.pointerLevel > 0:
+			reason = "length param is a pointer; use doubleCall"
+		}
+
+		if reason != "" {
+			log.WithField("command", p.parentCommand.registryName).
+				WithField("param", p.registryName).
+				WithField("role", p.role).
+				Error("cannot apply param role from exceptions.json: " + reason)
+			p.role = roleAuto
+		} else {
+			p.isConstParam = p.role == roleInputSlice
+		}
+	}
+
+	if p.lenExpr != "" && p.pointerLevel > 0 && p.isConstParam {
+		log.WithField("command", p.parentCommand.registryName).
+			WithField("param", p.registryName).
+			WithField("len", p.lenExpr).
+			Error("cannot apply len from exceptions.json: an input slice's length is the length of the slice")
+		p.lenExpr = ""
+	}
+}
+
+// lengthExpr returns the Go expression for the length of a slice param
+func (p *commandParam) lengthExpr() string {
+	if p.lenExpr != "" {
+		return p.lenExpr
+	}
+	if p.lenMemberParam != nil {
+		return p.lenMemberParam.publicName
+	}
+	return "len(" + p.publicName + ")"
+}
+
+// lenSourceParam returns the slice that a length param takes its value from, or nil if every slice using the length
+// is allocated by the binding.
+func (p *commandParam) lenSourceParam() *commandParam {
+	for _, q := range p.isLenMemberFor {
+		if q.role != roleOutputSlice {
+			return q
+		}
+	}
+	return nil
+}
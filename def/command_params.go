@@ -0,0 +1,220 @@
+package def
+
+import (
+	"strings"
+
+	"github.com/iancoleman/strcase"
+	log "github.com/sirupsen/logrus"
+)
+
+// paramKind is the classification of a command parameter, which decides where the parameter appears in the Go
+// function (parameter list, result list, or neither) and what code converts it for the trampoline.
+type paramKind string
+
+const (
+	// kindValue is a non-pointer input, passed through (with translation if needed)
+	kindValue paramKind = "value"
+	// kindLength is a non-pointer length, set from the length of an input slice
+	kindLength paramKind = "length"
+	// kindLengthInput is a non-pointer length provided by the caller, e.g. when every slice using it is allocated by
+	// the binding, or the data it measures is an unsafe.Pointer
+	kindLengthInput paramKind = "lengthInput"
+	// kindDerived is a non-pointer value computed from a len expression in exceptions.json
+	kindDerived paramKind = "derived"
+
+	// kindInputPointer is a const pointer to a single input value
+	kindInputPointer paramKind = "inputPointer"
+	// kindInputSlice is a const pointer to an array, passed by the caller as a slice
+	kindInputSlice paramKind = "inputSlice"
+	// kindInputSliceEmbeddedLen is an input array whose length is a member of a struct param. This is not supported
+	// yet, and nil is passed to Vulkan. See vkGetAccelerationStructureBuildSizesKHR.
+	kindInputSliceEmbeddedLen paramKind = "inputSliceEmbeddedLen"
+	// kindInputSliceAltLen is an input array with an altlen, which the caller must match with the value it is
+	// encoded in. See vkCmdSetSampleMaskEXT.
+	kindInputSliceAltLen paramKind = "inputSliceAltLen"
+
+	// kindOutputValue is a single value written by Vulkan and returned to the caller
+	kindOutputValue paramKind = "outputValue"
+	// kindOutputSlice is an array allocated by the binding, with a length from another param or exceptions.json,
+	// and returned to the caller
+	kindOutputSlice paramKind = "outputSlice"
+	// kindOutputSliceEmbeddedLen is an array allocated by the binding, with a length from a member of a struct param.
+	// See vkAllocateCommandBuffers.
+	kindOutputSliceEmbeddedLen paramKind = "outputSliceEmbeddedLen"
+	// kindUserAllocated is an array allocated by the caller and written by Vulkan
+	kindUserAllocated paramKind = "userAllocated"
+	// kindDoubleCallArray is an array returned through the two-call idiom: the length is queried, the array is
+	// allocated, and the command is called again to fill it
+	kindDoubleCallArray paramKind = "doubleCallArray"
+	// kindDoubleCallLength is the pointer length of a double-call array. It is passed to the trampoline with the
+	// first array it measures, rather than at its own position.
+	kindDoubleCallLength paramKind = "doubleCallLength"
+
+	// kindReturn is the command's return value
+	kindReturn paramKind = "return"
+)
+
+// paramClass is the result of classifying a command parameter during Resolve. PrintPublicDeclaration only prints
+// code for the class; it makes no decisions of its own.
+type paramClass struct {
+	kind paramKind
+	// length is the Go expression for the length of a slice, or for the value of a length or derived param
+	length string
+	// translate is set when the public and internal representations differ; for input slices it applies to each
+	// element
+	translate bool
+	// publicType is the type of the param in the Go function signature. The trampoline always receives the param's
+	// resolvedType, as its internal type.
+	publicType TypeDefiner
+
+	// firstArray and lastArray are set on the first and last double-call arrays sharing a length param
+	firstArray, lastArray bool
+}
+
+// classifyParams classifies every parameter, once they have all been resolved (since length params are linked as
+// each array is resolved), and builds the Go function's parameter, result and trampoline lists.
+func (t *commandType) classifyParams() {
+	t.inputParams = make([]*commandParam, 0)
+	t.resultParams = make([]*commandParam, 0)
+	t.trampolineParams = make([]*commandParam, 0)
+	t.returnValueParam = nil
+
+	if t.resolvedReturnType != nil && t.resolvedReturnType.RegistryName() != "void" {
+		retParam := &commandParam{}
+		retParam.resolvedType = t.resolvedReturnType
+		retParam.publicName = strcase.ToLowerCamel(t.resolvedReturnType.PublicName())
+		retParam.class = paramClass{kind: kindReturn, publicType: t.resolvedReturnType}
+		t.resultParams = append(t.resultParams, retParam)
+		t.returnValueParam = retParam
+	}
+
+	for _, p := range t.parameters {
+		p.class = p.classify()
+		c := &p.class
+
+		switch c.kind {
+		case kindInputPointer, kindInputSlice, kindInputSliceEmbeddedLen, kindInputSliceAltLen, kindUserAllocated,
+			kindLengthInput, kindValue:
+			t.inputParams = append(t.inputParams, p)
+			t.trampolineParams = append(t.trampolineParams, p)
+
+		case kindOutputValue, kindOutputSlice, kindOutputSliceEmbeddedLen:
+			t.trampolineParams = append(t.trampolineParams, p)
+			t.resultParams = append(t.resultParams, p)
+
+		case kindDoubleCallArray:
+			if c.firstArray {
+				t.trampolineParams = append(t.trampolineParams, p.lenMemberParam)
+			}
+			t.trampolineParams = append(t.trampolineParams, p)
+			t.resultParams = append(t.resultParams, p)
+
+		case kindLength, kindDerived:
+			t.trampolineParams = append(t.trampolineParams, p)
+		}
+
+		log.WithField("command", t.registryName).
+			WithField("param", p.registryName).
+			WithField("kind", c.kind).
+			WithField("length", c.length).
+			WithField("translate", c.translate).
+			Debug("Classified command parameter")
+	}
+
+	t.bindingParamCount = len(t.trampolineParams)
+}
+
+// classify returns the class of the param, and renames the internal variable where the generated code needs a
+// distinct name for it.
+func (p *commandParam) classify() paramClass {
+	rval := paramClass{
+		translate:  !p.resolvedType.IsIdenticalPublicAndInternal(),
+		publicType: p.resolvedType,
+	}
+
+	if p.resolvedType.Category() != CatPointer {
+		switch {
+		case p.lenExpr != "":
+			rval.kind, rval.length = kindDerived, p.lenExpr
+		case p.isLenMemberFor != nil:
+			source := p.lenSourceParam()
+			if source == nil || (!p.isAlwaysOptional && p.resolvedType.PublicName() == "unsafe.Pointer") {
+				rval.kind = kindLengthInput
+			} else {
+				rval.kind, rval.length = kindLength, "len("+source.publicName+")"
+			}
+		default:
+			rval.kind = kindValue
+		}
+
+		if p.requiresTranslation {
+			// Non-pointer types have the same name for internal and public, but we would attempt redefine that
+			// variable in the function body. Postfix the param name with the internal type to avoid the conflict.
+			// See vkWaitForFences for an example involving Bool32
+			p.internalName = p.internalName + "_" + p.resolvedType.InternalName()
+		}
+		return rval
+	}
+
+	pointsAt := p.resolvedType.(*pointerType).resolvedPointsAtType
+
+	if p.isConstParam {
+		switch {
+		case p.lenMemberParam != nil || p.role == roleInputSlice:
+			// Elements are translated one at a time
+			rval.kind, rval.length, rval.translate = kindInputSlice, p.lengthExpr(), p.requiresTranslation
+		case strings.Contains(p.lenSpec, "->"):
+			rval.kind, rval.length = kindInputSliceEmbeddedLen, p.lenSpec
+		case p.altLenSpec != "":
+			rval.kind, rval.length = kindInputSliceAltLen, p.altLenSpec
+		default:
+			rval.kind = kindInputPointer
+		}
+		return rval
+	}
+
+	switch {
+	case p.role == roleOutputSlice:
+		rval.kind, rval.length = kindOutputSlice, p.lengthExpr()
+
+	case p.role == roleUserAllocated:
+		rval.kind = kindUserAllocated
+
+	case p.lenMemberParam != nil && p.lenMemberParam.resolvedType.Category() == CatPointer:
+		rval.kind, rval.length = kindDoubleCallArray, p.lengthExpr()
+		rval.firstArray = p.lenMemberParam.isLenMemberFor[0] == p
+		rval.lastArray = p.lenMemberParam.isLenMemberFor[len(p.lenMemberParam.isLenMemberFor)-1] == p
+
+	case p.lenMemberParam != nil:
+		// If the length param is also the length of a const (input) param, then this is an output allocated by the
+		// binding based on the length of the other slice. Otherwise, the caller must allocate it; e.g.
+		// vkGetQueryPoolResults matches this rule.
+		allocForOutput := false
+		for _, q := range p.lenMemberParam.isLenMemberFor {
+			allocForOutput = allocForOutput || q.isConstParam
+		}
+		if allocForOutput {
+			rval.kind, rval.length = kindOutputSlice, p.lengthExpr()
+		} else {
+			rval.kind = kindUserAllocated
+		}
+
+	case p.isLenMemberFor != nil:
+		rval.kind = kindDoubleCallLength
+
+	case p.lenSpec != "" && p.lenSpec != "1":
+		// As of 1.3.290, vkGetDeviceSubpassShadingMaxWorkgroupSizeHUAWEI breaks the rules and has a static length of
+		// 1 in the len field, so it is handled as a single value below.
+		stringParts := strings.Split(p.lenSpec, "->")
+		rval.kind, rval.length = kindOutputSliceEmbeddedLen, stringParts[0]+"."+stringParts[1]
+
+	default:
+		// The caller receives the value, not the pointer
+		rval.kind, rval.publicType = kindOutputValue, pointsAt
+		if !rval.translate {
+			p.internalName = "ptr_" + p.internalName
+		}
+	}
+
+	return rval
+}
@@ -0,0 +1,178 @@
+package def
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/antchfx/xmlquery"
+	"github.com/tidwall/gjson"
+)
+
+const classifyRegistry = `<registry>
+<types>
+	<type requires="vk_platform" name="void"/>
+	<type requires="vk_platform" name="uint32_t"/>
+	<type requires="vk_platform" name="uint64_t"/>
+	<type category="basetype">typedef <type>uint32_t</type> <name>VkBool32</name>;</type>
+	<type category="basetype">typedef <type>uint32_t</type> <name>VkSampleMask</name>;</type>
+	<type category="handle"><type>VK_DEFINE_HANDLE</type>(<name>VkDevice</name>)</type>
+	<type category="handle"><type>VK_DEFINE_HANDLE</type>(<name>VkCommandBuffer</name>)</type>
+	<type category="handle"><type>VK_DEFINE_NON_DISPATCHABLE_HANDLE</type>(<name>VkFence</name>)</type>
+	<type category="struct" name="VkCommandBufferAllocateInfo">
+		<member><type>uint32_t</type> <name>commandBufferCount</name></member>
+	</type>
+</types>
+<commands>
+	<command>
+		<proto><type>void</type> <name>vkWaitForFences</name></proto>
+		<param><type>VkDevice</type> <name>device</name></param>
+		<param><type>uint32_t</type> <name>fenceCount</name></param>
+		<param len="fenceCount">const <type>VkFence</type>* <name>pFences</name></param>
+		<param><type>VkBool32</type> <name>waitAll</name></param>
+	</command>
+	<command>
+		<proto><type>void</type> <name>vkResetFences</name></proto>
+		<param><type>VkDevice</type> <name>device</name></param>
+		<param><type>uint32_t</type> <name>fenceCount</name></param>
+		<param len="fenceCount">const <type>VkFence</type>* <name>pFences</name></param>
+	</command>
+	<command>
+		<proto><type>void</type> <name>vkEnumerateFences</name></proto>
+		<param><type>VkDevice</type> <name>device</name></param>
+		<param optional="false,true"><type>uint32_t</type>* <name>pFenceCount</name></param>
+		<param optional="true" len="pFenceCount"><type>VkFence</type>* <name>pFences</name></param>
+	</command>
+	<command>
+		<proto><type>void</type> <name>vkGetQueryPoolResults</name></proto>
+		<param><type>VkDevice</type> <name>device</name></param>
+		<param><type>uint32_t</type> <name>dataSize</name></param>
+		<param len="dataSize"><type>uint32_t</type>* <name>pData</name></param>
+	</command>
+	<command>
+		<proto><type>void</type> <name>vkAllocateCommandBuffers</name></proto>
+		<param><type>VkDevice</type> <name>device</name></param>
+		<param>const <type>VkCommandBufferAllocateInfo</type>* <name>pAllocateInfo</name></param>
+		<param len="pAllocateInfo->commandBufferCount"><type>VkCommandBuffer</type>* <name>pCommandBuffers</name></param>
+	</command>
+	<command>
+		<proto><type>void</type> <name>vkResetCommandBuffers</name></proto>
+		<param><type>VkDevice</type> <name>device</name></param>
+		<param>const <type>VkCommandBufferAllocateInfo</type>* <name>pAllocateInfo</name></param>
+		<param len="pAllocateInfo->commandBufferCount">const <type>VkCommandBuffer</type>* <name>pCommandBuffers</name></param>
+	</command>
+	<command>
+		<proto><type>void</type> <name>vkCmdSetSampleMaskEXT</name></proto>
+		<param><type>VkCommandBuffer</type> <name>commandBuffer</name></param>
+		<param><type>uint32_t</type> <name>samples</name></param>
+		<param len="latexmath:[ \lceil{\mathit{samples} \over 32}\rceil ]" altlen="(samples + 31) / 32">const <type>VkSampleMask</type>* <name>pSampleMask</name></param>
+	</command>
+	<command>
+		<proto><type>void</type> <name>vkCreateFence</name></proto>
+		<param><type>VkDevice</type> <name>device</name></param>
+		<param><type>VkFence</type>* <name>pFence</name></param>
+	</command>
+	<command>
+		<proto><type>void</type> <name>vkGetFenceData</name></proto>
+		<param><type>VkDevice</type> <name>device</name></param>
+		<param><type>uint32_t</type> <name>dataSize</name></param>
+		<param><type>void</type>* <name>pData</name></param>
+	</command>
+</commands>
+</registry>`
+
+const classifyExceptions = `{ "command": {
+	"vkResetFences": { "params": {
+		"pFences": { "role": "inputSlice", "len": "3" }
+	} },
+	"vkGetFenceData": { "params": {
+		"dataSize": { "len": "uint32(len(data))" },
+		"pData": { "role": "userAllocated" }
+	} }
+} }`
+
+// readClassifyRegistry reads the test registry with the repository's exceptions.json, then applies the test's own
+// command exceptions, the same way gen reads each category
+func readClassifyRegistry(t *testing.T) TypeRegistry {
+	t.Helper()
+
+	doc, err := xmlquery.Parse(strings.NewReader(classifyRegistry))
+	if err != nil {
+		t.Fatal(err)
+	}
+	exceptions, err := os.ReadFile("../exceptions.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	jsonDoc := gjson.ParseBytes(exceptions)
+
+	tr, vr := make(TypeRegistry), make(ValueRegistry)
+	for tc := CatNone; tc < CatMaximum; tc++ {
+		xml, json := tc.ReadFns()
+		if xml != nil {
+			xml(doc, tr, vr, "vulkan")
+		}
+		if json != nil {
+			json(jsonDoc, tr, vr)
+		}
+	}
+	ReadCommandExceptionsFromJSON(gjson.Parse(classifyExceptions), tr, vr)
+
+	return tr
+}
+
+func TestClassifyParams(t *testing.T) {
+	tr := readClassifyRegistry(t)
+
+	cases := []struct {
+		command, param string
+		kind           paramKind
+		length         string
+		translate      bool
+	}{
+		// Input slice, with its length param set from the slice. Pointers and Bool32 are translated, but the elements
+		// of a slice of handles are not.
+		{"vkWaitForFences", "pFences", kindInputSlice, "fenceCount", false},
+		{"vkWaitForFences", "fenceCount", kindLength, "len(fences)", false},
+		{"vkWaitForFences", "waitAll", kindValue, "", true},
+		// A len override on an input slice is rejected
+		{"vkResetFences", "pFences", kindInputSlice, "fenceCount", false},
+		{"vkResetFences", "fenceCount", kindLength, "len(fences)", false},
+		// Double-call array and its pointer length
+		{"vkEnumerateFences", "pFences", kindDoubleCallArray, "fenceCount", true},
+		{"vkEnumerateFences", "pFenceCount", kindDoubleCallLength, "", false},
+		// User-allocated array, whose length is not shared with an input slice
+		{"vkGetQueryPoolResults", "pData", kindUserAllocated, "", true},
+		{"vkGetQueryPoolResults", "dataSize", kindLength, "len(data)", false},
+		// Output slice with a length embedded in a struct param
+		{"vkAllocateCommandBuffers", "pCommandBuffers", kindOutputSliceEmbeddedLen, "pAllocateInfo.commandBufferCount", true},
+		// Input slice with a -> length
+		{"vkResetCommandBuffers", "pCommandBuffers", kindInputSliceEmbeddedLen, "pAllocateInfo->commandBufferCount", true},
+		// Input slice with an altlen
+		{"vkCmdSetSampleMaskEXT", "pSampleMask", kindInputSliceAltLen, "(samples + 31) / 32", true},
+		// Singular output
+		{"vkCreateFence", "pFence", kindOutputValue, "", false},
+		// A len override on a non-pointer param, derived from a user-allocated slice
+		{"vkGetFenceData", "dataSize", kindDerived, "uint32(len(data))", false},
+		{"vkGetFenceData", "pData", kindUserAllocated, "", true},
+	}
+
+	for _, c := range cases {
+		td, found := tr[c.command]
+		if !found {
+			t.Errorf("%s was not read", c.command)
+			continue
+		}
+		td.Resolve(tr, nil)
+
+		p := td.(*commandType).findParam(c.param)
+		if p == nil {
+			t.Errorf("%s has no param %s", c.command, c.param)
+			continue
+		}
+		if p.class.kind != c.kind || p.class.length != c.length || p.class.translate != c.translate {
+			t.Errorf("%s %s: got kind %q, length %q, translate %v; want kind %q, length %q, translate %v",
+				c.command, c.param, p.class.kind, p.class.length, p.class.translate, c.kind, c.length, c.translate)
+		}
+	}
+}
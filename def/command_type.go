@@ -20,11 +20,22 @@ type commandType struct {
 
 	staticCodeRef string
 
-	parameters []*commandParam
+	parameters     []*commandParam
+	paramOverrides map[string]*paramOverride
 
 	bindingParams     []*commandParam
 	returnParams      []*commandParam
 	bindingParamCount int
+
+	// The Go function's parameters and results, and the params passed to the trampoline, set by classifyParams.
+	// returnValueParam is also in resultParams.
+	inputParams, resultParams []*commandParam
+	trampolineParams          []*commandParam
+	returnValueParam          *commandParam
+
+	// The Go function's parameter list, result list and body, recorded by PrintPublicDeclaration for NewTypeData
+	paramList, resultList string
+	body                  string
 }
 
 // Exceptions to camelCase rules used for function return params
@@ -71,12 +82,18 @@ func (t *commandType) Resolve(tr TypeRegistry, vr ValueRegistry) *IncludeSet {
 		}
 	}
 
+	t.applyParamOverrides()
+
 	for _, p := range t.parameters {
 		p.parentCommand = t
 
 		iset.MergeWith(p.Resolve(tr, vr))
 	}
 
+	if !t.IsAlias() && t.staticCodeRef == "" {
+		t.classifyParams()
+	}
+
 	iset.ResolvedTypes[t.registryName] = t
 
 	t.isResolved = true
@@ -89,333 +106,187 @@ func (t *commandType) PrintPublicDeclaration(w io.Writer) {
 		fmt.Fprintf(w, "var %s = %s\n\n", t.PublicName(), t.staticCodeRef)
 		return
 	} else if t.IsAlias() {
+		t.PrintDeprecationNote(w)
 		fmt.Fprintf(w, "var %s = %s\n\n", t.PublicName(), t.resolvedAliasType.PublicName())
 		return
 	}
 
 	preamble, epilogue, outputTranslation := &strings.Builder{}, &strings.Builder{}, &strings.Builder{}
 
-	funcReturnParams := make([]*commandParam, 0)
-	var trampolineReturns *commandParam
-	funcInputParams := make([]*commandParam, 0)
-	funcTrampolineParams := make([]*commandParam, 0)
-
-	if t.resolvedReturnType.RegistryName() != "void" {
-		retParam := &commandParam{}
-		retParam.resolvedType = t.resolvedReturnType
-		retParam.publicName = strcase.ToLowerCamel(t.resolvedReturnType.PublicName())
-		funcReturnParams = append(funcReturnParams, retParam)
-		trampolineReturns = retParam
-	}
-
-	// Iterative dev process:
-	// Start with a simple output scenario - vkEndCommandBuffer takes a single
-	// input (ignore for the moment) and returns a VkResult
-	// Deal with simple inputs, like handles and primitive/scalar types
-	// Convert inputs that require translation
-	// Deal with simple outputs through pointers - e.g. GetBufferMemoryRequirements
-	// Deal with slice inputs - eg WaitForFences - fenceCount, pFences
-
 	for _, p := range t.parameters {
-		// After classification, the params need to be in one or more of:
-		// - Go function parameters
-		// - Go return values
-		// - Trampoline parameters, with or without translation
+		c := &p.class
 
-		if p.resolvedType.Category() == CatPointer {
+		switch c.kind {
+		case kindInputSlice:
 			paramTypeAsPointer := p.resolvedType.(*pointerType)
+			if c.translate {
+				fmt.Fprintf(preamble, "  // %s is an input slice that requires translation to an internal type\n", p.publicName)
+				fmt.Fprintf(preamble, "  var %s unsafe.Pointer\n", p.internalName)
+				fmt.Fprintf(preamble, "  if len(%s) > 0 {\n", p.publicName)
+				fmt.Fprintf(preamble, "    sl_%s := make([]%s, %s)\n", p.publicName, paramTypeAsPointer.resolvedPointsAtType.InternalName(), c.length)
+				fmt.Fprintf(preamble, "    for i, v := range %s {\n", p.publicName)
+				fmt.Fprintf(preamble, "      sl_%s[i] = %s\n", p.publicName, paramTypeAsPointer.resolvedPointsAtType.TranslateToInternal("v"))
+				fmt.Fprintf(preamble, "    }\n")
+				fmt.Fprintf(preamble, "    %s = unsafe.Pointer(&sl_%s[0])\n", p.internalName, p.publicName)
+				fmt.Fprintf(preamble, "  }\n")
+				fmt.Fprintln(preamble)
 
-			if p.isConstParam {
-				funcInputParams = append(funcInputParams, p)
-
-				if p.lenMemberParam != nil {
-					// Parameter is an input array/slice
-
-					if p.requiresTranslation {
-						fmt.Fprintf(preamble, "  // %s is an input slice that requires translation to an internal type\n", p.publicName)
-						fmt.Fprintf(preamble, "  var %s unsafe.Pointer\n", p.internalName)
-						fmt.Fprintf(preamble, "  if len(%s) > 0 {\n", p.publicName)
-						fmt.Fprintf(preamble, "    sl_%s := make([]%s, %s)\n", p.publicName, paramTypeAsPointer.resolvedPointsAtType.InternalName(), p.lenMemberParam.publicName)
-						fmt.Fprintf(preamble, "    for i, v := range %s {\n", p.publicName)
-						fmt.Fprintf(preamble, "      sl_%s[i] = %s\n", p.publicName, paramTypeAsPointer.resolvedPointsAtType.TranslateToInternal("v"))
-						fmt.Fprintf(preamble, "    }\n")
-						fmt.Fprintf(preamble, "    %s = unsafe.Pointer(&sl_%s[0])\n", p.internalName, p.publicName)
-						fmt.Fprintf(preamble, "  }\n")
-						fmt.Fprintln(preamble)
-
-						funcTrampolineParams = append(funcTrampolineParams, p)
-
-					} else {
-						// Parameter can be directly used (once we get a pointer
-						// to the first element)
-						fmt.Fprintf(preamble, "  // %s is an input slice of values that do not need translation used\n", p.publicName)
-						fmt.Fprintf(preamble, "  var %s unsafe.Pointer\n", p.internalName)
-						fmt.Fprintf(preamble, "  if %s != nil {\n", p.publicName)
-						fmt.Fprintf(preamble, "    %s = unsafe.Pointer(&%s[0])\n", p.internalName, p.publicName)
-						fmt.Fprintf(preamble, "  }\n")
-						fmt.Fprintln(preamble)
-						funcTrampolineParams = append(funcTrampolineParams, p)
-					}
-
-				} else if strings.Contains(p.lenSpec, "->") {
-					// This is an edge case where the length of the return array (slice) is embedded in a struct, which
-					// is another parameter. See vkGetAccelerationStructureBuildSizesKHR for an example (perhaps the
-					// only example).
-					lenparts := strings.Split(p.lenSpec, "->")
-					otherParamInternalName := lenparts[0]
-					// otherParamMemberName := lenparts[1]
-
-					fmt.Fprintf(preamble, "  // %s is an input slice that requires translation to an internal type; length is embedded in %s\n", p.publicName, otherParamInternalName)
-					fmt.Fprintf(preamble, "  %s := unsafe.Pointer(nil)\n", p.internalName) //, otherParamInternalName, otherParamMemberName)
-					fmt.Fprintf(preamble, "  // WARNING TODO - passing nil pointer to get to a version that will compile. THIS VULKAN CALL WILL FAIL!")
-
-					funcTrampolineParams = append(funcTrampolineParams, p)
-
-				} else if p.altLenSpec != "" {
-					// This is another edge case where the length of the array is embedded in a bitfield. For now, the
-					// user must provide the bitfield and must ensure that their slice is the appropriate length.
-					// See vkCmdSetSampleMaskEXT for an example. Addressed as "no action" relative to other slices in
-					// Resolve(). Fix for issue #17
-					fmt.Fprintf(preamble, "  // %s is an edge case input slice, with an alternative length encoding. Developer must provide the length themselves.\n", p.publicName)
-					fmt.Fprintf(preamble, "  // No handling for internal vs. external types at this time, the only case this appears as of 1.3.240 is a handle type with a bitfield length encoding\n")
-					fmt.Fprintf(preamble, "  var %s *%s\n", p.internalName, p.resolvedType.(*pointerType).resolvedPointsAtType.PublicName())
-					fmt.Fprintf(preamble, "  if %s != nil {\n", p.publicName)
-					fmt.Fprintf(preamble, "    %s = &%s[0]\n", p.internalName, p.publicName)
-					fmt.Fprintf(preamble, "  }\n")
-
-					funcTrampolineParams = append(funcTrampolineParams, p)
+			} else {
+				// Parameter can be directly used (once we get a pointer
+				// to the first element)
+				fmt.Fprintf(preamble, "  // %s is an input slice of values that do not need translation used\n", p.publicName)
+				fmt.Fprintf(preamble, "  var %s unsafe.Pointer\n", p.internalName)
+				fmt.Fprintf(preamble, "  if %s != nil {\n", p.publicName)
+				fmt.Fprintf(preamble, "    %s = unsafe.Pointer(&%s[0])\n", p.internalName, p.publicName)
+				fmt.Fprintf(preamble, "  }\n")
+				fmt.Fprintln(preamble)
+			}
 
-				} else {
-					// Parameter is a singular input
-					if p.resolvedType.IsIdenticalPublicAndInternal() {
-						fmt.Fprintf(preamble, "// Parameter is a singular input, pass direct - %s\n", p.publicName)
-						fmt.Fprintf(preamble, "  var %s unsafe.Pointer\n", p.internalName)
-						fmt.Fprintf(preamble, "  if %s != nil {\n", p.publicName)
-						fmt.Fprintf(preamble, "    %s = unsafe.Pointer(%s)\n", p.internalName, p.publicName)
-						fmt.Fprintf(preamble, "  }\n")
-						fmt.Fprintln(preamble)
-						funcTrampolineParams = append(funcTrampolineParams, p)
-
-					} else {
-						fmt.Fprintf(preamble, "// Parameter is a singular input, requires translation - %s\n", p.publicName)
-						// Special handling for strings, which come in as "" instead of nil
-						nullValue := "nil"
-						if p.resolvedType.PublicName() == "string" {
-							// Vulkan accepts NULL or an empty string as the same value
-							nullValue = `""`
-						}
-
-						fmt.Fprintf(preamble, "  var %s %s\n", p.internalName, p.resolvedType.InternalName())
-						fmt.Fprintf(preamble, "  if %s != %s {\n", p.publicName, nullValue)
-						fmt.Fprintf(preamble, "    %s = %s\n", p.internalName, p.resolvedType.TranslateToInternal(p.publicName))
-						fmt.Fprintf(preamble, "  }\n")
-						fmt.Fprintln(preamble)
-						funcTrampolineParams = append(funcTrampolineParams, p)
-
-					}
+		case kindInputSliceEmbeddedLen:
+			otherParamInternalName := strings.Split(c.length, "->")[0]
+
+			fmt.Fprintf(preamble, "  // %s is an input slice that requires translation to an internal type; length is embedded in %s\n", p.publicName, otherParamInternalName)
+			fmt.Fprintf(preamble, "  %s := unsafe.Pointer(nil)\n", p.internalName)
+			fmt.Fprintf(preamble, "  // WARNING TODO - passing nil pointer to get to a version that will compile. THIS VULKAN CALL WILL FAIL!")
+
+		case kindInputSliceAltLen:
+			// The user must provide the value the length is encoded in, and must ensure that their slice is the
+			// appropriate length. Fix for issue #17
+			fmt.Fprintf(preamble, "  // %s is an edge case input slice, with an alternative length encoding. Developer must provide the length themselves.\n", p.publicName)
+			fmt.Fprintf(preamble, "  // No handling for internal vs. external types at this time, the only case this appears as of 1.3.240 is a handle type with a bitfield length encoding\n")
+			fmt.Fprintf(preamble, "  var %s *%s\n", p.internalName, p.resolvedType.(*pointerType).resolvedPointsAtType.PublicName())
+			fmt.Fprintf(preamble, "  if %s != nil {\n", p.publicName)
+			fmt.Fprintf(preamble, "    %s = &%s[0]\n", p.internalName, p.publicName)
+			fmt.Fprintf(preamble, "  }\n")
+
+		case kindInputPointer:
+			if !c.translate {
+				fmt.Fprintf(preamble, "// Parameter is a singular input, pass direct - %s\n", p.publicName)
+				fmt.Fprintf(preamble, "  var %s unsafe.Pointer\n", p.internalName)
+				fmt.Fprintf(preamble, "  if %s != nil {\n", p.publicName)
+				fmt.Fprintf(preamble, "    %s = unsafe.Pointer(%s)\n", p.internalName, p.publicName)
+				fmt.Fprintf(preamble, "  }\n")
+				fmt.Fprintln(preamble)
+
+			} else {
+				fmt.Fprintf(preamble, "// Parameter is a singular input, requires translation - %s\n", p.publicName)
+				// Special handling for strings, which come in as "" instead of nil
+				nullValue := "nil"
+				if p.resolvedType.PublicName() == "string" {
+					// Vulkan accepts NULL or an empty string as the same value
+					nullValue = `""`
 				}
+
+				fmt.Fprintf(preamble, "  var %s %s\n", p.internalName, p.resolvedType.InternalName())
+				fmt.Fprintf(preamble, "  if %s != %s {\n", p.publicName, nullValue)
+				fmt.Fprintf(preamble, "    %s = %s\n", p.internalName, p.resolvedType.TranslateToInternal(p.publicName))
+				fmt.Fprintf(preamble, "  }\n")
+				fmt.Fprintln(preamble)
+			}
+
+		case kindOutputSlice:
+			if p.role == roleOutputSlice {
+				fmt.Fprintf(preamble, "// %s is an output array that will be allocated by the binding, len is %s (set by exceptions.json)\n", p.publicName, c.length)
 			} else {
-				if p.lenMemberParam != nil { // lenSpec != "": spec might be a reference into another input, e.g. vkAllocateCommandBuffers, pAllocateInfo->commandBufferCount
-					// Parameter is an output
-					if p.lenMemberParam.resolvedType.Category() == CatPointer {
-						if p.lenMemberParam.isLenMemberFor[0] == p {
-
-							// Parameter is a double-call array output
-							fmt.Fprintf(preamble, "// %s is a double-call array output\n", p.publicName)
-							// Allocate the length param and stub the slice
-							fmt.Fprintf(preamble, "  var %s %s\n", p.lenMemberParam.publicName, p.lenMemberParam.resolvedType.(*pointerType).resolvedPointsAtType.PublicName())
-							fmt.Fprintf(preamble, "  %s := &%s\n", p.lenMemberParam.internalName, p.lenMemberParam.publicName)
-
-							fmt.Fprintf(preamble, "// first trampoline happens here; also, still need to check returned Result value\n")
-							funcTrampolineParams = append(funcTrampolineParams, p.lenMemberParam)
-
-						}
-
-						// Need distinction between identical interal/external types and those that need to be
-						// translated
-						if p.resolvedType.IsIdenticalPublicAndInternal() {
-							fmt.Fprintf(preamble, "// Identical internal and external")
-							// fmt.Fprintf(preamble, "  var %s %s\n", p.publicName, p.resolvedType.PublicName())
-							fmt.Fprintf(epilogue, "  %s = make([]%s, %s)\n", p.publicName, p.resolvedType.PublicName(), p.lenMemberParam.publicName)
-							fmt.Fprintf(epilogue, "  %s := &%s[0]\n", p.internalName, p.publicName)
-							fmt.Fprintln(epilogue)
-
-						} else {
-							fmt.Fprintf(preamble, "// NOT identical internal and external, result needs translation\n")
-							fmt.Fprintf(preamble, "  var %s %s\n", p.internalName, p.resolvedType.InternalName())
-							fmt.Fprintf(epilogue, "  sl_%s := make([]%s, %s)\n", p.internalName, p.resolvedType.(*pointerType).resolvedPointsAtType.InternalName(), p.lenMemberParam.publicName)
-							fmt.Fprintf(epilogue, "  %s = make(%s, %s)\n", p.publicName, p.resolvedType.PublicName(), p.lenMemberParam.publicName)
-							fmt.Fprintf(epilogue, "  %s = &sl_%s[0]\n", p.internalName, p.internalName)
-							fmt.Fprintln(epilogue)
-
-							fmt.Fprintf(outputTranslation,
-								`for i := range sl_%s {
+				fmt.Fprintf(preamble, "// %s is an output array that will be allocated by the binding, len is from %s\n", p.publicName, p.lenMemberParam.publicName)
+			}
+			fmt.Fprintf(preamble, "  %s = make([]%s, %s)\n", p.publicName, p.resolvedType.(*pointerType).resolvedPointsAtType.PublicName(), c.length)
+			fmt.Fprintf(preamble, "  %s := unsafe.Pointer(&%s[0])\n", p.internalName, p.publicName)
+			fmt.Fprintln(preamble)
+
+		case kindUserAllocated:
+			if p.role == roleUserAllocated {
+				fmt.Fprintf(preamble, "// %s is a user-allocated array input that will be written to (set by exceptions.json)\n", p.publicName)
+			} else {
+				fmt.Fprintf(preamble, "// %s is a user-allocated array input that will be written to\n", p.publicName)
+			}
+			fmt.Fprintf(preamble, "  %s := unsafe.Pointer(&%s[0])\n", p.internalName, p.publicName)
+			fmt.Fprintln(preamble)
+
+		case kindDoubleCallArray:
+			if c.firstArray {
+				fmt.Fprintf(preamble, "// %s is a double-call array output\n", p.publicName)
+				// Allocate the length param and stub the slice
+				fmt.Fprintf(preamble, "  var %s %s\n", p.lenMemberParam.publicName, p.lenMemberParam.resolvedType.(*pointerType).resolvedPointsAtType.PublicName())
+				fmt.Fprintf(preamble, "  %s := &%s\n", p.lenMemberParam.internalName, p.lenMemberParam.publicName)
+
+				fmt.Fprintf(preamble, "// first trampoline happens here; also, still need to check returned Result value\n")
+			}
+
+			if !c.translate {
+				fmt.Fprintf(preamble, "// Identical internal and external")
+				fmt.Fprintf(epilogue, "  %s = make([]%s, %s)\n", p.publicName, p.resolvedType.PublicName(), c.length)
+				fmt.Fprintf(epilogue, "  %s := &%s[0]\n", p.internalName, p.publicName)
+				fmt.Fprintln(epilogue)
+
+			} else {
+				fmt.Fprintf(preamble, "// NOT identical internal and external, result needs translation\n")
+				fmt.Fprintf(preamble, "  var %s %s\n", p.internalName, p.resolvedType.InternalName())
+				fmt.Fprintf(epilogue, "  sl_%s := make([]%s, %s)\n", p.internalName, p.resolvedType.(*pointerType).resolvedPointsAtType.InternalName(), c.length)
+				fmt.Fprintf(epilogue, "  %s = make(%s, %s)\n", p.publicName, p.resolvedType.PublicName(), c.length)
+				fmt.Fprintf(epilogue, "  %s = &sl_%s[0]\n", p.internalName, p.internalName)
+				fmt.Fprintln(epilogue)
+
+				fmt.Fprintf(outputTranslation,
+					`for i := range sl_%s {
 	%s[i] = *%s
 }
 `, p.internalName, p.publicName, p.resolvedType.TranslateToPublic("sl_"+p.internalName+"[i]"))
-						}
-
-						funcTrampolineParams = append(funcTrampolineParams, p)
-						funcReturnParams = append(funcReturnParams, p)
-
-						if p.lenMemberParam.isLenMemberFor[len(p.lenMemberParam.isLenMemberFor)-1] == p {
-							// If there is more than one array to allocate, make sure we only call trampoline on the last one
-							fmt.Fprintf(epilogue, "// Trampoline call after last array allocation\n")
-							t.printTrampolineCall(epilogue, funcTrampolineParams, trampolineReturns)
-							fmt.Fprintln(epilogue)
-
-							// If the output requires translation, iterate the slice and translate here
-							fmt.Fprintf(epilogue, outputTranslation.String())
-
-						}
-
-					} else if p.lenMemberParam != nil {
-						// If the length parameter references a const param in addition to
-						// this one (which we have already established is not
-						// const), then the length is derived from the length of
-						// the the other param, which will be an input slice.
-						//
-						// Therefore, this will be an output allocated by
-						// the binding based on the length of the other slice.
-						//
-						// Otherwise, this parameter must be pre-allocated by
-						// the user? Or it must be an input?
-
-						// Check for other const param in p.lenMemberParam
-						allocForOutput := false
-						for _, q := range p.lenMemberParam.isLenMemberFor {
-							allocForOutput = allocForOutput || q.isConstParam
-						}
-
-						if allocForOutput {
-							fmt.Fprintf(preamble, "// %s is an output array that will be allocated by the binding, len is from %s\n", p.publicName, p.lenMemberParam.publicName)
-							fmt.Fprintf(preamble, "  %s = make([]%s, %s)\n", p.publicName, paramTypeAsPointer.resolvedPointsAtType.PublicName(), p.lenMemberParam.publicName)
-							fmt.Fprintf(preamble, "  %s := unsafe.Pointer(&%s[0])\n", p.internalName, p.publicName)
-							fmt.Fprintln(preamble)
-
-							funcTrampolineParams = append(funcTrampolineParams, p)
-							funcReturnParams = append(funcReturnParams, p)
-
-						} else {
-							fmt.Fprintf(preamble, "// %s is a user-allocated array input that will be written to\n", p.publicName)
-							// e.g., vkGetQueryPoolResults matches this rule
-							// I just want to accept a []byte, extract the
-							// length and push len+pointer to trampoline
-							// fmt.Fprintf(preamble, "  %s := len(%s)\n", p.lenMemberParam.publicName, p.publicName)
-							fmt.Fprintf(preamble, "  %s := unsafe.Pointer(&%s[0])\n", p.internalName, p.publicName)
-							fmt.Fprintln(preamble)
-
-							funcInputParams = append(funcInputParams, p)
-							funcTrampolineParams = append(funcTrampolineParams, p)
-						}
-
-					}
-				} else if p.isLenMemberFor != nil {
-					p.isOutput = false
+			}
 
-				} else {
-					// Parameter is user allocated but will be populated by Vulkan. Binding will treat it as an output only.
-					if p.lenSpec != "" && p.lenSpec != "1" {
-						// As of 1.3.290, vkGetDeviceSubpassShadingMaxWorkgroupSizeHUAWEI breaks the rules and has a static length of 1 in the len field.
-
-						if p.lenMemberParam == nil {
-							fmt.Fprintf(preamble, "// Parameter is binding-allocated array populated by Vulkan; length is possibly embedded in a struct (%s) - %s\n", p.lenSpec, p.publicName)
-							stringParts := strings.Split(p.lenSpec, "->")
-							translatedLenMember := stringParts[0] + "." + stringParts[1]
-
-							fmt.Fprintf(preamble, "  %s = make(%s, %s)\n", p.publicName, p.resolvedType.PublicName(), translatedLenMember)
-							fmt.Fprintf(preamble, "  %s := &%s[0]\n", p.internalName, p.publicName)
-
-							// At a practical level, this is only used to return an array of handles, we can avoid translation altogether; see
-							// AllocateCommandBuffers for an example. It is possible that a future API release will need
-							// updates here.
-
-						} else {
-							fmt.Fprintf(preamble, "// Parameter is binding-allocated array populated by Vulkan; length is provided by what? (%s) - %s\n", p.publicName, p.lenSpec)
-							panic("Parameter is binding-allocated array populated by Vulkan, length not provided?? " + p.lenSpec)
-						}
-
-						// rewrite the return param as a slice
-						derefedReturnParam := *p
-						// derefedReturnParam.resolvedType = paramTypeAsPointer.resolvedPointsAtType
-
-						funcTrampolineParams = append(funcTrampolineParams, p)
-						funcReturnParams = append(funcReturnParams, &derefedReturnParam)
-					} else {
-						if p.resolvedType.IsIdenticalPublicAndInternal() {
-							fmt.Fprintf(preamble, "// %s is a binding-allocated single return value and will be populated by Vulkan\n", p.publicName)
-
-							p.internalName = "ptr_" + p.internalName // This should really be done in resolve, not here
-
-							fmt.Fprintf(preamble, "  %s := &%s\n", p.internalName, p.publicName)
-							fmt.Fprintln(preamble)
-						} else {
-							fmt.Fprintf(preamble, "// %s is a binding-allocated single return value and will be populated by Vulkan, but requiring translation\n", p.publicName)
-							if p.resolvedType.Category() == CatPointer {
-								underlyingType := p.resolvedType.(*pointerType).resolvedPointsAtType
-								if underlyingType.Category() == CatStruct || underlyingType.Category() == CatUnion {
-									// Pointer type will end up calling Vulkanize()
-									fmt.Fprintf(preamble, "var %s %s = %s\n", p.internalName, p.resolvedType.InternalName(), p.resolvedType.TranslateToInternal(p.publicName))
-
-									fmt.Fprintf(epilogue, "  %s = %s\n", p.publicName, p.resolvedType.(*pointerType).resolvedPointsAtType.TranslateToPublic(p.internalName))
-								} else {
-									fmt.Fprintf(preamble, "var internal_%s %s = %s\n", p.publicName, underlyingType.InternalName(), underlyingType.TranslateToInternal(p.publicName))
-									fmt.Fprintf(preamble, "var %s = &internal_%s\n", p.internalName, p.publicName)
-									fmt.Fprintf(epilogue, "  %s = %s\n", p.publicName, underlyingType.TranslateToPublic("internal_"+p.publicName))
-								}
-							} else {
-								// Vulkan *must* be accepting a pointer, if it is planning to fill in any kind of data
-								panic("found non-pointer return value from vulkan!")
-							}
-
-							fmt.Fprintln(preamble)
-
-						}
-
-						// rewrite the return param as not a pointer
-						derefedReturnParam := *p
-						derefedReturnParam.resolvedType = paramTypeAsPointer.resolvedPointsAtType
-
-						funcTrampolineParams = append(funcTrampolineParams, p)
-						funcReturnParams = append(funcReturnParams, &derefedReturnParam)
-					}
-				}
+			if c.lastArray {
+				// If there is more than one array to allocate, make sure we only call trampoline on the last one. The
+				// second call takes the same params as the first, up to and including this one.
+				fmt.Fprintf(epilogue, "// Trampoline call after last array allocation\n")
+				t.printTrampolineCall(epilogue, t.trampolineParamsThrough(p), t.returnValueParam)
+				fmt.Fprintln(epilogue)
+
+				// If the output requires translation, iterate the slice and translate here
+				fmt.Fprintf(epilogue, outputTranslation.String())
 			}
-		} else {
-			// Non-pointer parameters
-			if p.isLenMemberFor != nil {
-				// A non-optional length parameter is the length of an input array
-				if !p.isAlwaysOptional && p.resolvedType.PublicName() == "unsafe.Pointer" {
-					funcInputParams = append(funcInputParams, p)
+
+		case kindOutputSliceEmbeddedLen:
+			fmt.Fprintf(preamble, "// Parameter is binding-allocated array populated by Vulkan; length is possibly embedded in a struct (%s) - %s\n", p.lenSpec, p.publicName)
+			fmt.Fprintf(preamble, "  %s = make(%s, %s)\n", p.publicName, p.resolvedType.PublicName(), c.length)
+			fmt.Fprintf(preamble, "  %s := &%s[0]\n", p.internalName, p.publicName)
+
+			// At a practical level, this is only used to return an array of handles, we can avoid translation altogether; see
+			// AllocateCommandBuffers for an example. It is possible that a future API release will need
+			// updates here.
+
+		case kindOutputValue:
+			if !c.translate {
+				fmt.Fprintf(preamble, "// %s is a binding-allocated single return value and will be populated by Vulkan\n", p.publicName)
+				fmt.Fprintf(preamble, "  %s := &%s\n", p.internalName, p.publicName)
+				fmt.Fprintln(preamble)
+			} else {
+				fmt.Fprintf(preamble, "// %s is a binding-allocated single return value and will be populated by Vulkan, but requiring translation\n", p.publicName)
+				underlyingType := c.publicType
+				if underlyingType.Category() == CatStruct || underlyingType.Category() == CatUnion {
+					// Pointer type will end up calling Vulkanize()
+					fmt.Fprintf(preamble, "var %s %s = %s\n", p.internalName, p.resolvedType.InternalName(), p.resolvedType.TranslateToInternal(p.publicName))
+
+					fmt.Fprintf(epilogue, "  %s = %s\n", p.publicName, underlyingType.TranslateToPublic(p.internalName))
 				} else {
-					fmt.Fprintf(preamble, "%s := len(%s)\n", p.publicName, p.isLenMemberFor[0].publicName)
+					fmt.Fprintf(preamble, "var internal_%s %s = %s\n", p.publicName, underlyingType.InternalName(), underlyingType.TranslateToInternal(p.publicName))
+					fmt.Fprintf(preamble, "var %s = &internal_%s\n", p.internalName, p.publicName)
+					fmt.Fprintf(epilogue, "  %s = %s\n", p.publicName, underlyingType.TranslateToPublic("internal_"+p.publicName))
 				}
 
-				p.isInput = false
-				p.isOutput = false
-
-				funcTrampolineParams = append(funcTrampolineParams, p)
+				fmt.Fprintln(preamble)
 			}
 
-			if p.requiresTranslation {
-				// Non-pointer types have the same name for internal and public,
-				// but we would attempt redefine that variable here. Postfix the
-				// param name with the internal type to avoid the conflict. See
-				// vkWaitForFences for an example involving Bool32
-				p.internalName = p.internalName + "_" + p.resolvedType.InternalName()
-				fmt.Fprintf(preamble, "%s := %s\n", p.internalName, p.resolvedType.TranslateToInternal(p.publicName))
-			}
+		case kindDerived:
+			// The value is derived from the other parameters, per exceptions.json
+			fmt.Fprintf(preamble, "%s := %s\n", p.publicName, c.length)
 
-			if p.isOutput {
-				funcReturnParams = append(funcReturnParams, p)
-				funcTrampolineParams = append(funcTrampolineParams, p)
-			}
-			if p.isInput {
-				funcInputParams = append(funcInputParams, p)
-				funcTrampolineParams = append(funcTrampolineParams, p)
-			}
+		case kindLength:
+			fmt.Fprintf(preamble, "%s := %s\n", p.publicName, c.length)
 		}
 
+		if p.resolvedType.Category() != CatPointer && p.requiresTranslation {
+			fmt.Fprintf(preamble, "%s := %s\n", p.internalName, p.resolvedType.TranslateToInternal(p.publicName))
+		}
 	}
 
 	specStringFromParams := func(sl []*commandParam) (string, bool) {
@@ -426,7 +297,7 @@ func (t *commandType) PrintPublicDeclaration(w io.Writer) {
 				remapResultToError = true
 				continue
 			}
-			fmt.Fprintf(sb, ", %s %s", param.publicName, param.resolvedType.PublicName())
+			fmt.Fprintf(sb, ", %s %s", param.publicName, param.class.publicType.PublicName())
 		}
 
 		if remapResultToError {
@@ -436,38 +307,49 @@ func (t *commandType) PrintPublicDeclaration(w io.Writer) {
 
 	}
 
-	t.bindingParamCount = len(funcTrampolineParams)
+	inputSpecString, _ := specStringFromParams(t.inputParams)
+	returnSpecString, hasResult := specStringFromParams(t.resultParams)
 
-	inputSpecString, _ := specStringFromParams(funcInputParams)
-	returnSpecString, hasResult := specStringFromParams(funcReturnParams)
+	body := &strings.Builder{}
+	fmt.Fprintln(body, preamble.String())
 
-	t.PrintDocLink(w)
-	fmt.Fprintf(w, "func %s(%s) (%s) {\n",
-		t.PublicName(),
-		inputSpecString,
-		returnSpecString)
+	t.printTrampolineCall(body, t.trampolineParams, t.returnValueParam)
+	fmt.Fprintln(body)
 
-	fmt.Fprintln(w, preamble.String())
-
-	t.printTrampolineCall(w, funcTrampolineParams, trampolineReturns)
-	fmt.Fprintln(w)
-
-	fmt.Fprintf(w, epilogue.String())
+	fmt.Fprintf(body, epilogue.String())
 
 	if hasResult {
-		fmt.Fprint(w, "  if r == Result(0) {\nr = SUCCESS\n}\n")
+		fmt.Fprint(body, "  if r == Result(0) {\nr = SUCCESS\n}\n")
 	}
 
-	if len(funcReturnParams) > 0 {
-		fmt.Fprintf(w, "  return\n")
+	if len(t.resultParams) > 0 {
+		fmt.Fprintf(body, "  return\n")
 	}
 
+	t.paramList, t.resultList, t.body = inputSpecString, returnSpecString, body.String()
+
+	t.PrintDocLink(w)
+	fmt.Fprintf(w, "func %s(%s) (%s) {\n",
+		t.PublicName(),
+		inputSpecString,
+		returnSpecString)
+	fmt.Fprint(w, t.body)
 	fmt.Fprintf(w, "}\n\n")
 
 	fmt.Fprintf(w, "var %s = &vkCommand{\"%s\", %d, %v, nil}\n",
 		t.RegistryName(), t.RegistryName(), t.bindingParamCount, t.resolvedReturnType != nil)
 }
 
+// trampolineParamsThrough returns the trampoline params up to and including p
+func (t *commandType) trampolineParamsThrough(p *commandParam) []*commandParam {
+	for i, q := range t.trampolineParams {
+		if q == p {
+			return t.trampolineParams[:i+1]
+		}
+	}
+	return t.trampolineParams
+}
+
 func trampStringFromParams(sl []*commandParam) string {
 	sb := &strings.Builder{}
 	for _, param := range sl {
@@ -496,6 +378,9 @@ func (t *commandType) printTrampolineCall(w io.Writer, trampParams []*commandPar
 	}
 }
 
+// RegisterImports adds unsafe, which the trampoline call refers to
+func (t *commandType) RegisterImports(reg map[string]bool) { reg["unsafe"] = true }
+
 // There is no internal declaration for commands, this function is empty
 func (t *commandType) PrintInternalDeclaration(w io.Writer) {}
 
@@ -518,11 +403,14 @@ type commandParam struct {
 	isLenMemberFor []*commandParam
 	lenMemberParam *commandParam
 
-	isInput, isOutput                          bool
-	isPublicSlice                              bool
-	isOutputArray                              bool
-	isDoubleCallArray, isDoubleCallArrayLength bool
-	requiresTranslation                        bool
+	// role, lenExpr and overridePublicName are set from exceptions.json; see paramOverride
+	role               paramRole
+	lenExpr            string
+	overridePublicName string
+
+	requiresTranslation bool
+
+	class paramClass
 }
 
 func (p *commandParam) Resolve(tr TypeRegistry, vr ValueRegistry) *IncludeSet {
@@ -567,6 +455,8 @@ func (p *commandParam) Resolve(tr TypeRegistry, vr ValueRegistry) *IncludeSet {
 		}
 	}
 
+	p.checkRole()
+
 	// if this param is undecorated, is not a pointer, and is not the length
 	// for another param, it is just straight input to pass through
 
@@ -575,41 +465,10 @@ func (p *commandParam) Resolve(tr TypeRegistry, vr ValueRegistry) *IncludeSet {
 	if p.resolvedType.Category() == CatPointer {
 		resTypeAsPointer := p.resolvedType.(*pointerType)
 		resTypeAsPointer.lenSpec = p.lenSpec
-
-		// if this param is an undecorated const pointer, it is input of a
-		// struct.
-		if p.isConstParam {
-			if p.lenSpec == "" {
-				p.isInput = true
-
-			} else if p.lenMemberParam != nil {
-				// if this param is a const pointer with a len specifier that maps to
-				// another parameter name, then this is an input array, represented as a
-				// slice on the public side
-				p.isInput = true
-				p.isPublicSlice = true
-			}
-		} else {
-			// if this param is a non-const pointer with a len specifier that maps
-			// to another parameter name, then this is a double-call output array
-			// EXCEPT when that len param is also for an input array. Then this is a
-			// single-call output array
-			if p.lenMemberParam != nil {
-				if len(p.lenMemberParam.isLenMemberFor) > 1 {
-					p.isOutputArray = true
-					p.isPublicSlice = true
-				} else {
-					p.isDoubleCallArray = true
-					p.isPublicSlice = true
-				}
-			} else if len(p.isLenMemberFor) > 0 {
-				// if this param is a non-const pointer that is a len specifier for
-				// another parameter, then this is a double-call array length
-				p.isDoubleCallArrayLength = true
-			}
+		if p.role != roleAuto && p.role != roleDoubleCall && !resTypeAsPointer.isArrayPointer() {
+			// A forced slice role needs a slice on the public side, even if the registry gives no length
+			resTypeAsPointer.lenSpec = string(p.role)
 		}
-	} else {
-		p.isInput = true
 	}
 
 	p.internalName = RenameIdentifier(p.registryName)
@@ -627,6 +486,14 @@ func (p *commandParam) Resolve(tr TypeRegistry, vr ValueRegistry) *IncludeSet {
 		p.publicName = string(unicode.ToLower(r)) + p.publicName[n:]
 	}
 
+	if p.overridePublicName != "" {
+		p.publicName = p.overridePublicName
+		if p.resolvedType.Category() != CatPointer {
+			// Non-pointer params are passed to the trampoline under the same name
+			p.internalName = p.overridePublicName
+		}
+	}
+
 	p.isAlwaysOptional = p.optionalParamString == "true"
 
 	if p.isAlwaysOptional || p.isConstParam || p.pointerLevel == 0 {
@@ -641,8 +508,8 @@ func (p *commandParam) Resolve(tr TypeRegistry, vr ValueRegistry) *IncludeSet {
 }
 
 func ReadCommandTypesFromXML(doc *xmlquery.Node, tr TypeRegistry, vr ValueRegistry, api string) {
-	cQueryString := fmt.Sprintf("//commands/command[@api='%s' or not(@api)]", api)
-	exQueryString := fmt.Sprintf("//extension/command[@api='%s' or not(@api)]", api)
+	cQueryString := fmt.Sprintf("//commands/command[%s]", ApiPredicate("api", api))
+	exQueryString := fmt.Sprintf("//extension/command[%s]", ApiPredicate("api", api))
 
 	for _, commandNode := range append(xmlquery.Find(doc, cQueryString), xmlquery.Find(doc, exQueryString)...) {
 		val := NewCommandFromXML(commandNode, api)
@@ -660,7 +527,7 @@ func NewCommandFromXML(elt *xmlquery.Node, api string) *commandType {
 		rval.registryName = xmlquery.FindOne(elt, "/proto/name").InnerText()
 		rval.returnTypeName = xmlquery.FindOne(elt, "/proto/type").InnerText()
 
-		paramQueryString := fmt.Sprintf("param[@api='%s' or not(@api)]", api)
+		paramQueryString := fmt.Sprintf("param[%s]", ApiPredicate("api", api))
 		for _, m := range xmlquery.Find(elt, paramQueryString) {
 			par := NewCommandParamFromXML(m, &rval)
 			rval.parameters = append(rval.parameters, par)
@@ -694,18 +561,43 @@ func ReadCommandExceptionsFromJSON(exceptions gjson.Result, tr TypeRegistry, vr
 			return true
 		} // Ignore comments
 
-		entry := NewCommandFromJSON(key, exVal)
+		var existing *commandType
+		if tmp, found := tr[key.String()]; found {
+			existing, _ = tmp.(*commandType)
+		}
+
+		entry := NewOrUpdateCommandFromJSON(key, exVal, existing)
 		tr[key.String()] = entry
 
 		return true
 	})
 }
 
-func NewCommandFromJSON(key, json gjson.Result) *commandType {
-	rval := commandType{}
+// NewOrUpdateCommandFromJSON applies a command exception. An entry with a staticCodeRef replaces the command
+// entirely; otherwise the entry updates the command read from vk.xml, e.g. to rename it or override how its
+// parameters are handled.
+func NewOrUpdateCommandFromJSON(key, json gjson.Result, existing *commandType) *commandType {
+	var rval *commandType = existing
+	if existing == nil || json.Get("staticCodeRef").Exists() {
+		rval = &commandType{}
+	}
+
 	rval.registryName = key.String()
-	rval.publicName = json.Get("publicName").String()
+	if json.Get("publicName").String() != "" {
+		rval.publicName = json.Get("publicName").String()
+	}
 	rval.staticCodeRef = json.Get("staticCodeRef").String()
 
-	return &rval
+	json.Get("params").ForEach(func(paramKey, paramVal gjson.Result) bool {
+		if paramKey.String() == "!comment" {
+			return true
+		}
+		if rval.paramOverrides == nil {
+			rval.paramOverrides = make(map[string]*paramOverride)
+		}
+		rval.paramOverrides[paramKey.String()] = newParamOverrideFromJSON(rval.registryName, paramKey.String(), paramVal)
+		return true
+	})
+
+	return rval
 }
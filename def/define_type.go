@@ -57,7 +57,7 @@ func (t *defineType) PrintPublicDeclaration(w io.Writer) {
 }
 
 func ReadDefineTypesFromXML(doc *xmlquery.Node, tr TypeRegistry, _ ValueRegistry, api string) {
-	queryString := fmt.Sprintf("//types/type[@category='define' and (@api='%s' or not(@api))]", api)
+	queryString := fmt.Sprintf("//types/type[@category='define' and %s]", ApiPredicate("api", api))
 	for _, node := range xmlquery.Find(doc, queryString) {
 		newType := NewDefineTypeFromXML(node)
 		if tr[newType.RegistryName()] != nil {
@@ -131,7 +131,8 @@ func ReadDefineExceptionsFromJSON(exceptions gjson.Result, tr TypeRegistry, vr V
 			} else {
 				logrus.WithField("key", key.String()).
 					WithField("value", exVal.String()).
-					Fatal("Fatal error when reading define exceptions: value for this key must be an object or the string \"!ignore\"")
+					Error("Skipping define exception: value for this key must be an object or the string \"!ignore\"")
+				return true
 			}
 		}
 
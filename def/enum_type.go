@@ -4,6 +4,8 @@ import (
 	"fmt"
 	"io"
 	"sort"
+	"strconv"
+	"strings"
 
 	"github.com/antchfx/xmlquery"
 	"github.com/sirupsen/logrus"
@@ -15,6 +17,11 @@ type enumType struct {
 	requiresTypeName     string
 	resolvedRequiresType TypeDefiner
 	isBitmaskType        bool
+	// bitwidth is 32 or 64 for the bits of a bitmask, from the bitwidth attribute of the values in vk.xml
+	bitwidth int
+
+	// platformValues are values of this type that are declared in a platform-specific file
+	platformValues []ValueDefiner
 }
 
 func (t *enumType) Category() TypeCategory { return CatEnum }
@@ -37,9 +44,28 @@ func (t *enumType) Resolve(tr TypeRegistry, vr ValueRegistry) *IncludeSet {
 	return rval
 }
 
+// bitmask returns the bitmask type that the enum holds the bits of, and is declared as an alias of. It returns nil if
+// the enum does not hold bits, or if no bitmask type requires it; the enum is then declared as a type of its own,
+// based on Flags or Flags64 for bits.
+func (t *enumType) bitmask() TypeDefiner {
+	if t.isBitmaskType && t.underlyingType != nil && t.underlyingType.Category() == CatBitmask {
+		return t.underlyingType
+	}
+	return nil
+}
+
+// flagsName returns the name of the type that bitmask values are used as, which the Parse function is named for
+func (t *enumType) flagsName() string {
+	if b := t.bitmask(); b != nil {
+		return b.PublicName()
+	}
+	return t.PublicName()
+}
+
 func (t *enumType) PrintPublicDeclaration(w io.Writer) {
-	if t.isBitmaskType {
-		fmt.Fprintf(w, "type %s = %s\n", t.PublicName(), t.underlyingType.PublicName())
+	if b := t.bitmask(); b != nil {
+		t.PrintDeprecationNote(w)
+		fmt.Fprintf(w, "type %s = %s\n", t.PublicName(), b.PublicName())
 	} else {
 		t.internalType.PrintPublicDeclaration(w)
 	}
@@ -57,10 +83,192 @@ func (t *enumType) PrintPublicDeclaration(w io.Writer) {
 		}
 		fmt.Fprint(w, ")\n\n")
 	}
+
+	t.printMethods(w)
+}
+
+// printMethods writes the String method and Parse function for the type
+func (t *enumType) printMethods(w io.Writer) {
+	t.printStringMethod(w)
+	t.printParseFunc(w)
+}
+
+// printStringMethod writes a String method for the type. For an ordinary enum it returns the public name of the
+// value, or the type name and number (e.g. "Result(-5)") for a value with no name. For the bits of a bitmask, it
+// returns the names of the set bits joined with "|", using formatFlags from the static files.
+func (t *enumType) printStringMethod(w io.Writer) {
+	values := t.stringValues()
+	if len(values) == 0 {
+		return
+	}
+
+	if t.isBitmaskType {
+		// The table is shared with the Parse function, which also needs every other name for each value
+		fmt.Fprintf(w, "var %s = []flagName{\n", t.tableName("Names"))
+		for _, v := range t.parseValues() {
+			fmt.Fprintf(w, "{%s, \"%s\"},\n", literalValueString(v), v.PublicName())
+		}
+		fmt.Fprint(w, "}\n\n")
+
+		fmt.Fprintf(w, "func (v %s) String() string {\n", t.PublicName())
+		fmt.Fprintf(w, "return formatFlags(uint64(v), %s)\n", t.tableName("Names"))
+		fmt.Fprint(w, "}\n\n")
+		return
+	}
+
+	fmt.Fprintf(w, "func (v %s) String() string {\n", t.PublicName())
+	fmt.Fprint(w, "switch v {\n")
+	for _, v := range values {
+		// Case on the value rather than the constant, since SUCCESS is not a constant
+		fmt.Fprintf(w, "case %s:\nreturn \"%s\"\n", v.ValueString(), v.PublicName())
+	}
+	fmt.Fprint(w, "}\n")
+	fmt.Fprintf(w, "return \"%s(\" + strconv.FormatInt(int64(v), 10) + \")\"\n", t.PublicName())
+	fmt.Fprint(w, "}\n\n")
+}
+
+// printParseFunc writes a Parse<Type> function, the inverse of String, using parseValue or parseFlags from the static
+// files. For the bits of a bitmask, the function is named for and returns the bitmask type, e.g.
+// ParseShaderStageFlags.
+func (t *enumType) printParseFunc(w io.Writer) {
+	values := t.parseValues()
+	if len(values) == 0 {
+		return
+	}
+	prefix := sharedPrefix(t.stringValues())
+
+	if t.isBitmaskType {
+		flagsName := t.flagsName()
+		fmt.Fprintf(w, "// Parse%s returns the flags named in s, separated by \"|\".\n", flagsName)
+		fmt.Fprintf(w, "// Each may be a number, or %s.\n", acceptedNames(prefix))
+		fmt.Fprintf(w, "func Parse%s(s string) (%s, error) {\n", flagsName, flagsName)
+		fmt.Fprintf(w, "v, err := parseFlags(\"%s\", s, \"%s\", %d, %s)\n", flagsName, prefix, t.bitwidth, t.tableName("Names"))
+		fmt.Fprintf(w, "return %s(v), err\n", flagsName)
+		fmt.Fprint(w, "}\n\n")
+		return
+	}
+
+	fmt.Fprintf(w, "var %s = map[string]%s{\n", t.tableName("Values"), t.PublicName())
+	for _, v := range values {
+		fmt.Fprintf(w, "\"%s\": %s,\n", v.PublicName(), literalValueString(v))
+	}
+	fmt.Fprint(w, "}\n\n")
+
+	fmt.Fprintf(w, "// Parse%s returns the %s named s.\n", t.PublicName(), t.PublicName())
+	fmt.Fprintf(w, "// It accepts %s.\n", acceptedNames(prefix))
+	fmt.Fprintf(w, "func Parse%s(s string) (%s, error) {\n", t.PublicName(), t.PublicName())
+	fmt.Fprintf(w, "return parseValue(\"%s\", s, \"%s\", %s)\n", t.PublicName(), prefix, t.tableName("Values"))
+	fmt.Fprint(w, "}\n\n")
+}
+
+// acceptedNames describes the names accepted by a Parse function, for its doc comment
+func acceptedNames(prefix string) string {
+	if prefix == "" {
+		return "the Go constant or registry name"
+	}
+	return fmt.Sprintf("the Go constant or registry name, with or without the %s prefix", prefix)
+}
+
+// tableName returns the name of a generated, unexported variable holding the type's values, e.g.
+// vkFormatValues
+func (t *enumType) tableName(suffix string) string {
+	return strings.ToLower(t.registryName[:1]) + t.registryName[1:] + suffix
+}
+
+// sortedValues returns every value of the type, including values declared in platform-specific files, sorted
+func (t *enumType) sortedValues() []ValueDefiner {
+	rval := make([]ValueDefiner, 0, len(t.values)+len(t.platformValues))
+	rval = append(rval, t.values...)
+	rval = append(rval, t.platformValues...)
+	sort.Sort(ByValue(rval))
+	return rval
+}
+
+// stringValues returns the values named by the String method, sorted: each value of the type except for aliases and
+// any value equal to an earlier one.
+func (t *enumType) stringValues() []ValueDefiner {
+	rval := make([]ValueDefiner, 0, len(t.values)+len(t.platformValues))
+	seen := make(map[string]bool)
+	for _, v := range t.sortedValues() {
+		if v.IsAlias() {
+			continue
+		}
+		key := v.ValueString()
+		if n, ok := parseIntegerValue(key); ok {
+			key = strconv.FormatInt(n, 10)
+		}
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		rval = append(rval, v)
+	}
+	return rval
+}
+
+// parseValues returns the values accepted by the Parse function: the values from stringValues, followed by the
+// aliases and other values that String does not name.
+func (t *enumType) parseValues() []ValueDefiner {
+	rval := t.stringValues()
+	named := make(map[ValueDefiner]bool)
+	for _, v := range rval {
+		named[v] = true
+	}
+	for _, v := range t.sortedValues() {
+		if !named[v] {
+			rval = append(rval, v)
+		}
+	}
+	return rval
+}
+
+// sharedPrefix returns the prefix, ending in an underscore, that every value's public name starts with, e.g.
+// "FORMAT_" for VkFormat. It returns "" if there are fewer than two values or they have no prefix in common.
+func sharedPrefix(values []ValueDefiner) string {
+	if len(values) < 2 {
+		return ""
+	}
+	prefix := values[0].PublicName()
+	for _, v := range values[1:] {
+		for !strings.HasPrefix(v.PublicName(), prefix) {
+			prefix = prefix[:len(prefix)-1]
+		}
+	}
+	return prefix[:strings.LastIndex(prefix, "_")+1]
+}
+
+// literalValueString returns the value of v as a literal, following any aliases. Unlike the name of the aliased
+// constant, a literal can be used as an untyped value, and for SUCCESS, which is not a constant.
+func literalValueString(v ValueDefiner) string {
+	for v.IsAlias() {
+		if a, ok := v.(interface{ aliasTarget() ValueDefiner }); ok && a.aliasTarget() != nil {
+			v = a.aliasTarget()
+		} else {
+			break
+		}
+	}
+	return v.ValueString()
+}
+
+// RegisterImports adds strconv, which the String method of an ordinary enum refers to
+func (t *enumType) RegisterImports(reg map[string]bool) {
+	if !t.isBitmaskType && len(t.values)+len(t.platformValues) > 0 {
+		reg["strconv"] = true
+	}
+}
+
+// AddPlatformValues records values of td that are declared in a platform-specific file rather than with td, so that
+// the String method printed with td can name them. It has no effect if td is not an enum.
+func AddPlatformValues(td TypeDefiner, vals ValueRegistry) {
+	if t, ok := td.(*enumType); ok {
+		for _, v := range vals {
+			t.platformValues = append(t.platformValues, v)
+		}
+	}
 }
 
 func ReadEnumTypesFromXML(doc *xmlquery.Node, tr TypeRegistry, vr ValueRegistry, api string) {
-	queryString := fmt.Sprintf("//types/type[@category='enum' and (@api='%s' or not(@api))]", api)
+	queryString := fmt.Sprintf("//types/type[@category='enum' and %s]", ApiPredicate("api", api))
 
 	for _, node := range xmlquery.Find(doc, queryString) {
 		newType := NewEnumTypeFromXML(node)
@@ -69,7 +277,7 @@ func ReadEnumTypesFromXML(doc *xmlquery.Node, tr TypeRegistry, vr ValueRegistry,
 		}
 		tr[newType.RegistryName()] = newType
 
-		ReadEnumValuesFromXML(doc, newType, tr, vr)
+		ReadEnumValuesFromXML(doc, newType, tr, vr, api)
 	}
 }
 
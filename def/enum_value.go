@@ -57,6 +57,7 @@ func (v *enumValue) Resolve(tr TypeRegistry, vr ValueRegistry) *IncludeSet {
 }
 
 func (v *enumValue) PrintPublicDeclaration(w io.Writer) {
+	v.PrintDeprecationNote(w)
 	// Special case to allow SUCCESS Result to be treated as nil error. Must be separately defined as var, not const
 	if v.resolvedType.RegistryName() != "VkResult" || v.PublicName() != "SUCCESS" {
 		fmt.Fprintf(w, "%s %s = %s", v.PublicName(), v.resolvedType.PublicName(), v.ValueString())
@@ -74,12 +75,12 @@ func (v *enumValue) PrintPublicDeclaration(w io.Writer) {
 
 }
 
-func ReadApiConstantsFromXML(doc *xmlquery.Node, externalType TypeDefiner, tr TypeRegistry, vr ValueRegistry) {
+func ReadApiConstantsFromXML(doc *xmlquery.Node, externalType TypeDefiner, tr TypeRegistry, vr ValueRegistry, api string) {
 	var selector string
 	if externalType == nil {
-		selector = "//enums[@name='API Constants']/enum[not(@type)]"
+		selector = fmt.Sprintf("//enums[@name='API Constants']/enum[not(@type) and %s]", ApiPredicate("api", api))
 	} else {
-		selector = fmt.Sprintf("//enums[@name='API Constants']/enum[@type='%s']", externalType.RegistryName())
+		selector = fmt.Sprintf("//enums[@name='API Constants']/enum[@type='%s' and %s]", externalType.RegistryName(), ApiPredicate("api", api))
 	}
 	for _, node := range xmlquery.Find(doc, selector) {
 		valDef := NewEnumValueFromXML(externalType, node)
@@ -88,21 +89,35 @@ func ReadApiConstantsFromXML(doc *xmlquery.Node, externalType TypeDefiner, tr Ty
 	}
 }
 
-func ReadEnumValuesFromXML(doc *xmlquery.Node, td TypeDefiner, tr TypeRegistry, vr ValueRegistry) {
+func ReadEnumValuesFromXML(doc *xmlquery.Node, td TypeDefiner, tr TypeRegistry, vr ValueRegistry, api string) {
 	groupSearchNodes := xmlquery.Find(doc, fmt.Sprintf("//enums[@name='%s']", td.RegistryName()))
 
 	for _, groupNode := range groupSearchNodes {
-		coreVals := append(xmlquery.Find(groupNode, "/enum"))
-		extVals := xmlquery.Find(doc, fmt.Sprintf("//require/enum[@extends='%s']", td.RegistryName()))
+		coreVals := xmlquery.Find(groupNode, fmt.Sprintf("/enum[%s]", ApiPredicate("api", api)))
+		extVals := xmlquery.Find(doc, fmt.Sprintf("//require[%s]/enum[@extends='%s' and %s]",
+			ApiPredicate("api", api), td.RegistryName(), ApiPredicate("api", api)))
 
 		switch groupNode.SelectAttr("type") {
 		case "bitmask":
-			td.(*enumType).isBitmaskType = true
+			et := td.(*enumType)
+			et.isBitmaskType = true
+			// Until a bitmask type claims these bits, they are a type of their own
+			if groupNode.SelectAttr("bitwidth") == "64" {
+				et.bitwidth = 64
+				et.underlyingTypeName = "VkFlags64"
+			} else {
+				et.bitwidth = 32
+				et.underlyingTypeName = "VkFlags"
+			}
+
 			for _, enumNode := range coreVals {
 				valDef := NewBitmaskValueFromXML(td, enumNode)
 				valDef.isCore = true
-				vr[valDef.RegistryName()] = valDef
+				if valDef.checkBitpos(et.bitwidth) {
+					vr[valDef.RegistryName()] = valDef
+				}
 			}
+			// Bits added by a feature or extension are read again, and checked, by the feature's readRequireNode
 			for _, enumNode := range extVals {
 				valDef := NewBitmaskValueFromXML(td, enumNode)
 				valDef.isCore = false
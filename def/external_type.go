@@ -40,7 +40,7 @@ func (t *externalType) TranslateToInternal(inputVar string) string {
 }
 
 func ReadExternalTypesFromXML(doc *xmlquery.Node, tr TypeRegistry, vr ValueRegistry, api string) {
-	queryString := fmt.Sprintf("//types/type[not(@category) and (@api='%s' or not(@api))]", api)
+	queryString := fmt.Sprintf("//types/type[not(@category) and %s]", ApiPredicate("api", api))
 
 	for _, node := range xmlquery.Find(doc, queryString) {
 		typ := NewExternalTypeFromXML(node)
@@ -50,10 +50,10 @@ func ReadExternalTypesFromXML(doc *xmlquery.Node, tr TypeRegistry, vr ValueRegis
 
 		tr[typ.RegistryName()] = typ
 		// Read external enums
-		ReadApiConstantsFromXML(doc, typ, tr, vr)
+		ReadApiConstantsFromXML(doc, typ, tr, vr, api)
 	}
 	// Read aliased (untyped) external enums
-	ReadApiConstantsFromXML(doc, nil, tr, vr)
+	ReadApiConstantsFromXML(doc, nil, tr, vr, api)
 }
 
 func NewExternalTypeFromXML(node *xmlquery.Node) *externalType {
@@ -23,6 +23,8 @@ type genericType struct {
 	resolvedAliasType TypeDefiner
 
 	values []ValueDefiner
+
+	deprecationNote string
 }
 
 func (t *genericType) Category() TypeCategory { return CatNone }
@@ -53,6 +55,9 @@ func (t *genericType) SetAliasType(td TypeDefiner) {
 	t.aliasTypeName = td.RegistryName()
 }
 
+func (t *genericType) SetDeprecationNote(note string) { t.deprecationNote = note }
+func (t *genericType) DeprecationNote() string        { return t.deprecationNote }
+
 func (t *genericType) IsAlias() bool { return t.resolvedAliasType != nil }
 
 func (t *genericType) AllValues() []ValueDefiner {
@@ -106,4 +111,16 @@ func (t *genericType) PrintDocLink(w io.Writer) {
 		fmt.Fprint(w, t.comment, "\n// ")
 	}
 	fmt.Fprintf(w, "See https://www.khronos.org/registry/vulkan/specs/1.3-extensions/man/html/%s.html\n", t.RegistryName())
+	if t.deprecationNote != "" {
+		fmt.Fprintln(w, "//")
+		t.PrintDeprecationNote(w)
+	}
+}
+
+// PrintDeprecationNote writes a standalone "Deprecated:" comment line if the type has been deprecated, for
+// declarations that do not print a full doc link.
+func (t *genericType) PrintDeprecationNote(w io.Writer) {
+	if t.deprecationNote != "" {
+		fmt.Fprintf(w, "// Deprecated: %s\n", t.deprecationNote)
+	}
 }
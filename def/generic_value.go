@@ -21,6 +21,8 @@ type genericValue struct {
 
 	isResolved bool
 	isCore     bool
+
+	deprecationNote string
 }
 
 func (v *genericValue) RegistryName() string { return v.registryName }
@@ -39,9 +41,22 @@ func (v *genericValue) UnderlyingTypeName() string { return v.underlyingTypeName
 
 func (v *genericValue) ResolvedType() TypeDefiner { return v.resolvedType }
 
+func (v *genericValue) SetDeprecationNote(note string) { v.deprecationNote = note }
+func (v *genericValue) DeprecationNote() string        { return v.deprecationNote }
+
+// PrintDeprecationNote writes a "Deprecated:" comment line above the value's declaration, if needed
+func (v *genericValue) PrintDeprecationNote(w io.Writer) {
+	if v.deprecationNote != "" {
+		fmt.Fprintf(w, "// Deprecated: %s\n", v.deprecationNote)
+	}
+}
+
 func (v *genericValue) IsAlias() bool { return v.aliasValueName != "" }
 func (v *genericValue) IsCore() bool  { return v.isCore }
 
+// aliasTarget returns the value that v aliases, once resolved
+func (v *genericValue) aliasTarget() ValueDefiner { return v.resolvedAliasValue }
+
 func (v *genericValue) Resolve(tr TypeRegistry, vr ValueRegistry) *IncludeSet {
 	if v.isResolved {
 		return NewIncludeSet()
@@ -67,5 +82,6 @@ func (v *genericValue) Resolve(tr TypeRegistry, vr ValueRegistry) *IncludeSet {
 }
 
 func (v *genericValue) PrintPublicDeclaration(w io.Writer) {
+	v.PrintDeprecationNote(w)
 	fmt.Fprintf(w, "%s %s = %s\n", v.PublicName(), v.resolvedType.PublicName(), v.ValueString())
 }
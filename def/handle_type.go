@@ -44,7 +44,7 @@ func (t *handleType) PrintPublicDeclaration(w io.Writer) {
 }
 
 func ReadHandleTypesFromXML(doc *xmlquery.Node, tr TypeRegistry, _ ValueRegistry, api string) {
-	queryString := fmt.Sprintf("//types/type[@category='handle' and (@api='%s' or not(@api))]", api)
+	queryString := fmt.Sprintf("//types/type[@category='handle' and %s]", ApiPredicate("api", api))
 
 	for _, node := range xmlquery.Find(doc, queryString) {
 		newType := NewHandleTypeFromXML(node)
@@ -77,7 +77,12 @@ func ReadHandleExceptionsFromJSON(exceptions gjson.Result, tr TypeRegistry, vr V
 			return true
 		} // Ignore comments
 
-		entry := NewHandleTypeFromJSON(key, exVal)
+		var existing *handleType
+		if tmp, found := tr[key.String()]; found {
+			existing, _ = tmp.(*handleType)
+		}
+
+		entry := NewOrUpdateHandleTypeFromJSON(key, exVal, existing)
 		tr[key.String()] = entry
 
 		exVal.Get("constants").ForEach(func(ck, cv gjson.Result) bool {
@@ -95,13 +100,25 @@ func ReadHandleExceptionsFromJSON(exceptions gjson.Result, tr TypeRegistry, vr V
 
 }
 
-func NewHandleTypeFromJSON(key, json gjson.Result) TypeDefiner {
-	rval := handleType{}
+// NewOrUpdateHandleTypeFromJSON applies a handle exception. An entry for a handle read from vk.xml only changes the
+// fields it sets, e.g. to rename the handle; any other entry (such as VK_DEFINE_HANDLE, which vk.xml declares as a
+// define) creates a new handle type from the JSON alone.
+func NewOrUpdateHandleTypeFromJSON(key, json gjson.Result, existing *handleType) TypeDefiner {
+	var rval *handleType = existing
+	if existing == nil {
+		rval = &handleType{}
+	}
 	rval.registryName = key.String()
-	rval.publicName = json.Get("publicName").String()
-	rval.underlyingTypeName = json.Get("underlyingType").String()
 
-	rval.comment = json.Get("comment").String()
+	if existing == nil || json.Get("publicName").Exists() {
+		rval.publicName = json.Get("publicName").String()
+	}
+	if existing == nil || json.Get("underlyingType").Exists() {
+		rval.underlyingTypeName = json.Get("underlyingType").String()
+	}
+	if existing == nil || json.Get("comment").Exists() {
+		rval.comment = json.Get("comment").String()
+	}
 
-	return &rval
+	return rval
 }
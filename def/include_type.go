@@ -37,7 +37,7 @@ func (t *includeType) Resolve(tr TypeRegistry, vr ValueRegistry) *IncludeSet {
 }
 
 func ReadIncludeTypesFromXML(doc *xmlquery.Node, tr TypeRegistry, _ ValueRegistry, api string) {
-	queryString := fmt.Sprintf("//types/type[@category='include' and (@api='%s' or @api='')]", api)
+	queryString := fmt.Sprintf("//types/type[@category='include' and %s]", ApiPredicate("api", api))
 
 	for _, node := range xmlquery.Find(doc, queryString) {
 		typ := NewIncludeTypeFromXML(node)
@@ -90,6 +90,9 @@ func NewOrUpdateIncludeTypeFromJSON(key string, exception gjson.Result, tr TypeR
 	return updatedEntry
 }
 
+// RegisterImports adds the Go packages listed in the include's exceptions.json entry
 func (t *includeType) RegisterImports(reg map[string]bool) {
-	reg[t.PublicName()] = true
+	for _, i := range t.goImports {
+		reg[i] = true
+	}
 }
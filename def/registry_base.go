@@ -1,10 +1,10 @@
 package def
 
 import (
-	"fmt"
 	"io"
 	"sort"
 	"strconv"
+	"strings"
 
 	"github.com/antchfx/xmlquery"
 	"github.com/tidwall/gjson"
@@ -112,11 +112,19 @@ type Resolver interface {
 	IsIdenticalPublicAndInternal() bool
 }
 
+// Deprecator carries the reason a type, command or value should no longer be used. A non-empty note is printed as
+// a "Deprecated:" paragraph in the generated doc comment.
+type Deprecator interface {
+	SetDeprecationNote(string)
+	DeprecationNote() string
+}
+
 type TypeDefiner interface {
 	Category() TypeCategory
 	Namer
 	Resolver
 	Printer
+	Deprecator
 
 	AllValues() []ValueDefiner
 	PushValue(ValueDefiner)
@@ -150,9 +158,14 @@ func (m ImportMap) SortedKeys() []string {
 
 type ByName []TypeDefiner
 
-func (a ByName) Len() int           { return len(a) }
-func (a ByName) Swap(i, j int)      { a[i], a[j] = a[j], a[i] }
-func (a ByName) Less(i, j int) bool { return a[i].PublicName() < a[j].PublicName() }
+func (a ByName) Len() int      { return len(a) }
+func (a ByName) Swap(i, j int) { a[i], a[j] = a[j], a[i] }
+func (a ByName) Less(i, j int) bool {
+	if a[i].PublicName() != a[j].PublicName() {
+		return a[i].PublicName() < a[j].PublicName()
+	}
+	return a[i].RegistryName() < a[j].RegistryName()
+}
 
 type ValueDefiner interface {
 	RegistryName() string
@@ -169,20 +182,57 @@ type ValueDefiner interface {
 
 	IsAlias() bool
 	IsCore() bool
+
+	Deprecator
 }
 
+// ByValue sorts values numerically where the value is an integer (decimal, hex, or a bit position written as
+// "1 << n"), followed by any other literal values as strings, followed by aliases ordered by the name of the value
+// they alias. Ties are broken by name, so that the order never depends on the order values were read in.
 type ByValue []ValueDefiner
 
-// TODO: also sort by bitmask values and aliases
 func (a ByValue) Len() int      { return len(a) }
 func (a ByValue) Swap(i, j int) { a[i], a[j] = a[j], a[i] }
 func (a ByValue) Less(i, j int) bool {
-	iNum, err1 := strconv.Atoi(a[i].ValueString())
-	jNum, err2 := strconv.Atoi(a[j].ValueString())
-	if err1 == nil && err2 == nil {
-		return iNum < jNum
+	ki, kj := newValueSortKey(a[i]), newValueSortKey(a[j])
+	switch {
+	case ki.rank != kj.rank:
+		return ki.rank < kj.rank
+	case ki.num != kj.num:
+		return ki.num < kj.num
+	case ki.str != kj.str:
+		return ki.str < kj.str
+	case a[i].PublicName() != a[j].PublicName():
+		return a[i].PublicName() < a[j].PublicName()
 	}
-	return a[i].ValueString() < a[j].ValueString()
+	return a[i].RegistryName() < a[j].RegistryName()
+}
+
+type valueSortKey struct {
+	rank int // 0 for integers, 1 for other literals, 2 for aliases
+	num  int64
+	str  string
+}
+
+func newValueSortKey(v ValueDefiner) valueSortKey {
+	if v.IsAlias() {
+		return valueSortKey{rank: 2, str: v.ValueString()}
+	}
+	if n, ok := parseIntegerValue(v.ValueString()); ok {
+		return valueSortKey{rank: 0, num: n}
+	}
+	return valueSortKey{rank: 1, str: v.ValueString()}
+}
+
+// parseIntegerValue parses a value string as written by vk-gen, e.g. "-1000", "0x00000004" or "1 << 3"
+func parseIntegerValue(s string) (int64, bool) {
+	if base, shift, found := strings.Cut(s, "<<"); found {
+		b, err1 := strconv.ParseInt(strings.TrimSpace(base), 0, 64)
+		n, err2 := strconv.ParseUint(strings.TrimSpace(shift), 0, 6)
+		return b << n, err1 == nil && err2 == nil
+	}
+	n, err := strconv.ParseInt(strings.TrimSpace(s), 0, 64)
+	return n, err == nil
 }
 
 type ByValuePublicName []ValueDefiner // add for cleanup/issue-3
@@ -192,39 +242,11 @@ func (a ByValuePublicName) Swap(i, j int) { a[i], a[j] = a[j], a[i] }
 func (a ByValuePublicName) Less(i, j int) bool {
 	iNum, err1 := strconv.Atoi(a[i].PublicName())
 	jNum, err2 := strconv.Atoi(a[j].PublicName())
-	if err1 == nil && err2 == nil {
+	if err1 == nil && err2 == nil && iNum != jNum {
 		return iNum < jNum
 	}
-	return a[i].PublicName() < a[j].PublicName()
-}
-
-func WriteStringerCommands(w io.Writer, defs []TypeDefiner, cat TypeCategory, filenameBase string) {
-	typesPerCallLimit := 32
-
-	types := ""
-	i := 0
-	fileCount := 0
-
-	// catString := strings.ToLower(cat.String())
-	// catString := strings.ToLower(strings.TrimPrefix(cat.String(), "Cat"))
-
-	for j, v := range defs {
-
-		if v.Category() == cat && len(v.AllValues()) > 0 {
-			types += v.PublicName() + ","
-			i++
-		} else {
-			continue
-		}
-
-		if i == typesPerCallLimit-1 || j == len(defs)-1 { // Limit the number of types per call to stringer
-			outFile := fmt.Sprintf("%s_string_%d.go", filenameBase, fileCount)
-			types = types[:len(types)-1]
-			fmt.Fprintf(w, "//go:generate stringer -output=%s -type=%s\n", outFile, types)
-
-			types = ""
-			fileCount++
-			i = 0
-		}
+	if a[i].PublicName() != a[j].PublicName() {
+		return a[i].PublicName() < a[j].PublicName()
 	}
+	return a[i].RegistryName() < a[j].RegistryName()
 }
@@ -0,0 +1,89 @@
+package def
+
+import (
+	"strings"
+
+	"github.com/sirupsen/logrus"
+	"github.com/tidwall/gjson"
+)
+
+// memberOverride holds the exceptions.json settings for one member of a struct:
+//
+//	"struct": { "VkStructName": { "members": { "memberName": {
+//	  "publicName": "Name",
+//	  "typeName": "registry type name",
+//	  "hide": true,
+//	  "expose": true,
+//	  "len": "otherMember",
+//	  "comment": "text"
+//	} } } }
+//
+// Member keys are registry names. "typeName" replaces the member's type, including any pointer levels, with another
+// type in the registry, e.g. "void*" to pass the member as an unsafe.Pointer. "hide" removes the member from the
+// public struct, leaving the zero value in the Vulkan struct. "expose" keeps a length member in the public struct
+// instead of setting it from the length of its slice. "len" replaces the registry's len attribute; give several slices
+// the same len to have them share one count member, which is set to the length of the longest slice.
+type memberOverride struct {
+	publicName string
+	typeName   string
+	hide       bool
+	expose     bool
+	lenSpec    string
+	comment    string
+}
+
+func newMemberOverrideFromJSON(json gjson.Result) *memberOverride {
+	return &memberOverride{
+		publicName: json.Get("publicName").String(),
+		typeName:   json.Get("typeName").String(),
+		hide:       json.Get("hide").Bool(),
+		expose:     json.Get("expose").Bool(),
+		lenSpec:    json.Get("len").String(),
+		comment:    json.Get("comment").String(),
+	}
+}
+
+// applyMemberOverrides copies the exceptions.json overrides onto the struct's members, before they are resolved
+func (t *structType) applyMemberOverrides() {
+	for name, o := range t.memberOverrides {
+		var m *structMember
+		for _, candidate := range t.members {
+			if candidate.registryName == name {
+				m = candidate
+				break
+			}
+		}
+		if m == nil {
+			logrus.WithField("registry name", t.registryName).
+				WithField("member", name).
+				Warn("exceptions.json overrides a member that the struct does not have")
+			continue
+		}
+
+		m.overridePublicName = o.publicName
+		m.hidden = o.hide
+		m.forceInclude = o.expose
+		if o.comment != "" {
+			m.comment = o.comment
+		}
+
+		if o.typeName != "" {
+			m.typeRegistryName = o.typeName
+			m.pointerDepth = 0
+			m.fixedLengthArray = false
+			m.lenSpecString, m.lenSpecs, m.altLenSpec = "", []string{""}, ""
+		}
+
+		if o.lenSpec != "" {
+			if m.fixedLengthArray {
+				logrus.WithField("registry name", t.registryName).
+					WithField("member", name).
+					Warn("cannot override len of a fixed length array member")
+			} else {
+				m.lenSpecString = o.lenSpec
+				m.lenSpecs = strings.Split(o.lenSpec, ",")
+				m.altLenSpec = ""
+			}
+		}
+	}
+}
@@ -18,6 +18,8 @@ type structType struct {
 
 	forceIncludeMemberName string
 	forceIncludeComment    string
+
+	memberOverrides map[string]*memberOverride
 }
 
 type structMember struct {
@@ -41,6 +43,13 @@ type structMember struct {
 	forceInclude       bool
 	comment            string
 	noAutoValidityFlag bool
+
+	// deprecated holds the registry's deprecated attribute, e.g. "ignored"
+	deprecated string
+
+	// overridePublicName and hidden are set from exceptions.json; see memberOverride
+	overridePublicName string
+	hidden             bool
 }
 
 func (t *structType) Category() TypeCategory { return CatStruct }
@@ -78,10 +87,12 @@ func (t *structType) Resolve(tr TypeRegistry, vr ValueRegistry) *IncludeSet {
 
 	rb := NewIncludeSet()
 
+	t.applyMemberOverrides()
+
 	// resolve each field of the struct
 	for _, m := range t.members {
 		rb.MergeWith(m.Resolve(tr, vr))
-		if m.lenSpecs[0] != "" { // len(m.lenSpecs) > 0 { // len specs is always populated, if non existent then lenSpecs[0] == ""
+		if m.lenSpecs[0] != "" && !m.hidden { // len(m.lenSpecs) > 0 { // len specs is always populated, if non existent then lenSpecs[0] == ""
 			for _, n := range t.members {
 				if n.RegistryName() == m.lenSpecs[0] {
 					// unsafe.Pointer is a C void*. Data type is arbitrary, size
@@ -102,8 +113,8 @@ func (t *structType) Resolve(tr TypeRegistry, vr ValueRegistry) *IncludeSet {
 				}
 			}
 		}
-		m.forceInclude = t.forceIncludeMemberName == m.registryName
-		if m.comment != "" {
+		m.forceInclude = m.forceInclude || t.forceIncludeMemberName == m.registryName
+		if m.comment != "" && t.forceIncludeComment != "" {
 			m.comment = m.comment + "; " + t.forceIncludeComment
 		}
 	}
@@ -160,6 +171,9 @@ func (t *structType) PrintPublicDeclaration(w io.Writer) {
 	}
 }
 
+// RegisterImports adds unsafe, which the internal struct and its translation functions refer to
+func (t *structType) RegisterImports(reg map[string]bool) { reg["unsafe"] = true }
+
 func (t *structType) PrintInternalDeclaration(w io.Writer) {
 
 	var preamble, structDecl, epilogue strings.Builder
@@ -227,6 +241,9 @@ func (t *structType) PrintInternalDeclaration(w io.Writer) {
 
 func (m *structMember) Resolve(tr TypeRegistry, vr ValueRegistry) *IncludeSet {
 	m.publicName = strings.Title(RenameIdentifier(m.registryName))
+	if m.overridePublicName != "" {
+		m.publicName = m.overridePublicName
+	}
 	m.internalName = RenameIdentifier(m.registryName)
 
 	// This automatically handles non-pointer types, i.e. pointerDepth == 0
@@ -275,7 +292,10 @@ func (m *structMember) Resolve(tr TypeRegistry, vr ValueRegistry) *IncludeSet {
 }
 
 func (m *structMember) IsIdenticalPublicAndInternal() bool {
+	// A hidden or renamed member means the public and internal structs no longer have the same fields, so the two
+	// cannot be converted directly
 	return m.resolvedValue == nil &&
+		!m.hidden && m.overridePublicName == "" &&
 		m.resolvedType.IsIdenticalPublicAndInternal() &&
 		m.pointerDepth == 0 &&
 		m.resolvedType.Category() != CatStruct &&
@@ -287,6 +307,23 @@ func (m *structMember) PrintPublicDeclaration(w io.Writer) {
 	if m.comment != "" {
 		fmt.Fprintln(w, "// ", m.comment)
 	}
+	// Members hidden from the public struct don't need a deprecation notice
+	hidden := m.resolvedValue != nil || m.isLenForOtherMember != nil || m.hidden
+	note := ""
+	switch {
+	case hidden && !m.forceInclude, m.deprecated == "":
+	case m.deprecated == "ignored":
+		note = fmt.Sprintf("%s is ignored by implementations", m.PublicName())
+	default:
+		note = fmt.Sprintf("%s is deprecated (%s)", m.PublicName(), m.deprecated)
+	}
+	if note != "" {
+		// The notice must be its own paragraph to be recognized
+		if m.comment != "" {
+			fmt.Fprintln(w, "//")
+		}
+		fmt.Fprintf(w, "// Deprecated: %s\n", note)
+	}
 
 	if m.forceInclude {
 		fmt.Fprintf(w, "%s %s // Forced include via exceptions.json\n", m.PublicName(), m.resolvedType.PublicName())
@@ -294,6 +331,8 @@ func (m *structMember) PrintPublicDeclaration(w io.Writer) {
 		fmt.Fprintf(w, "// %s = %s\n", m.PublicName(), m.resolvedValue.PublicName())
 	} else if m.isLenForOtherMember != nil {
 		fmt.Fprintf(w, "// %s\n", m.InternalName())
+	} else if m.hidden {
+		fmt.Fprintf(w, "// %s is hidden via exceptions.json\n", m.InternalName())
 	} else {
 		fmt.Fprintf(w, "%s %s\n", m.PublicName(), m.resolvedType.PublicName())
 	}
@@ -347,6 +386,8 @@ func (m *structMember) PrintVulanizeContent(preamble, structDecl, epilogue io.Wr
 
 		}
 
+	case m.hidden: // Hidden via exceptions.json, left as the zero value
+
 	case m.resolvedType.IsIdenticalPublicAndInternal(): // Base case
 		fmt.Fprintf(structDecl, "  %s : (%s)(s.%s),/*cb*/\n", m.InternalName(), m.resolvedType.InternalName(), m.PublicName())
 
@@ -378,6 +419,8 @@ func (m *structMember) PrintGoifyContent(preamble, structDecl, epilogue io.Write
 	switch true {
 	case m.resolvedValue != nil: // Edge case 1 never happens in returned strucs
 
+	case m.hidden:
+
 	case m.resolvedType.Category() == CatUnion:
 		fmt.Fprintf(structDecl, "  // Can't Goify union member %s\n", m.InternalName())
 
@@ -423,7 +466,7 @@ func (m *structMember) PrintGoifyContent(preamble, structDecl, epilogue io.Write
 }
 
 func ReadStructTypesFromXML(doc *xmlquery.Node, tr TypeRegistry, vr ValueRegistry, api string) {
-	queryString := fmt.Sprintf("//types/type[@category='struct' and (@api='%s' or not(@api))]", api)
+	queryString := fmt.Sprintf("//types/type[@category='struct' and %s]", ApiPredicate("api", api))
 
 	for _, node := range xmlquery.Find(doc, queryString) {
 		s := newStructTypeFromXML(node, api)
@@ -437,7 +480,7 @@ func newStructTypeFromXML(node *xmlquery.Node, api string) *structType {
 	rval.registryName = node.SelectAttr("name")
 	rval.isReturnedOnly = node.SelectAttr("returnedonly") == "true"
 
-	queryString := fmt.Sprintf("member[@api='%s' or not(@api)]", api)
+	queryString := fmt.Sprintf("member[%s]", ApiPredicate("api", api))
 	for _, mNode := range xmlquery.Find(node, queryString) {
 		rval.members = append(rval.members, newStructMemberFromXML(mNode))
 	}
@@ -476,6 +519,7 @@ func newStructMemberFromXML(node *xmlquery.Node) *structMember {
 	}
 
 	rval.noAutoValidityFlag = node.SelectAttr("noautovalidity") == "true"
+	rval.deprecated = node.SelectAttr("deprecated")
 
 	// Pointers are a little odd. Generally a pointer in C becomes a slice in
 	// Go, and struct members have a related length member. But in certain
@@ -531,5 +575,16 @@ func NewOrUpdateStructTypeFromJSON(key, json gjson.Result, existing *structType)
 	rval.forceIncludeMemberName = json.Get("forceIncludeMember").String()
 	rval.forceIncludeComment = json.Get("forceIncludeComment").String()
 
+	json.Get("members").ForEach(func(memberKey, memberVal gjson.Result) bool {
+		if memberKey.String() == "!comment" {
+			return true
+		}
+		if rval.memberOverrides == nil {
+			rval.memberOverrides = make(map[string]*memberOverride)
+		}
+		rval.memberOverrides[memberKey.String()] = newMemberOverrideFromJSON(memberVal)
+		return true
+	})
+
 	return rval
 }
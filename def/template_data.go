@@ -0,0 +1,213 @@
+package def
+
+import (
+	"io"
+	"strings"
+)
+
+// TypeData is the data model passed to an output template for one generated type. It only holds strings, bools and
+// slices of the other *Data types, so that templates don't depend on vk-gen's internal types.
+//
+// Public and Internal hold the declarations exactly as the built-in Go printers write them. Templates can use them
+// for anything they don't need to restyle.
+type TypeData struct {
+	Category     string // e.g. "struct"; the lower case TypeCategory without the Cat prefix
+	RegistryName string
+	PublicName   string
+	InternalName string
+	Comment      string
+	DocLink      string // The full doc comment, including the trailing newline
+	Deprecated   string // Deprecation note, without the "Deprecated:" prefix
+	AliasOf      string // Public name of the aliased type, if the type is an alias
+	Underlying   string // Public name of the underlying type of a handle, enum or bitmask
+
+	Values []ValueData
+
+	// Members is set for structs and unions
+	Members []MemberData
+
+	// Commands only
+	Params, Results       []ParamData
+	ParamList, ResultList string // The Go parameter and result lists, as printed in the function signature
+	Body                  string // The function body, without the enclosing braces
+	StaticCodeRef         string
+	BindingParamCount     int
+	HasReturn             bool
+
+	// IsBitmaskEnum is set for an enum that holds the bits of a bitmask, which is declared as an alias of the bitmask
+	IsBitmaskEnum bool
+	// Bitwidth is 32 or 64 for an enum holding the bits of a bitmask
+	Bitwidth int
+	// Methods holds the String method and Parse function generated for an enum
+	Methods string
+
+	Global, Init     string
+	Public, Internal string
+}
+
+// ValueData is the data model for a constant, e.g. an enum value.
+type ValueData struct {
+	RegistryName string
+	PublicName   string
+	Type         string // Public name of the value's type
+	Value        string
+	Comment      string
+	Deprecated   string
+	IsAlias      bool
+	Declaration  string // The declaration line(s) as printed inside a const block
+}
+
+// MemberData is the data model for a struct or union member.
+type MemberData struct {
+	RegistryName string
+	PublicName   string
+	InternalName string
+	PublicType   string
+	InternalType string
+	Comment      string
+	Hidden       bool   // Not a field of the public struct, e.g. sType or a length member
+	Declaration  string // The public struct field line(s) as printed by vk-gen
+	// UnionSetter is "pointer", "slice" or "value", describing the As<Member> setter generated for a union member
+	UnionSetter string
+}
+
+// ParamData is the data model for a Go function parameter or result.
+type ParamData struct {
+	Name string
+	Type string
+	// Kind is the classification of the Vulkan parameter, e.g. "inputSlice" or "doubleCallArray"
+	Kind string
+}
+
+// NewTypeData prints the type with the built-in printers and returns the data model for it. globalIndex and first
+// are passed to PrintGlobalDeclarations. As with the printers, NewTypeData must only be called once per type.
+func NewTypeData(td TypeDefiner, globalIndex int, first bool) TypeData {
+	rval := TypeData{
+		Category:     strings.ToLower(strings.TrimPrefix(td.Category().String(), "Cat")),
+		RegistryName: td.RegistryName(),
+		PublicName:   td.PublicName(),
+		InternalName: td.InternalName(),
+		Deprecated:   td.DeprecationNote(),
+	}
+
+	// Printers are called in the same order as in the Go backend, since some rely on state set by an earlier call
+	rval.Global = printString(func(w io.Writer) { td.PrintGlobalDeclarations(w, globalIndex, first) })
+	rval.Public = printString(td.PrintPublicDeclaration)
+	rval.Internal = printString(td.PrintInternalDeclaration)
+	rval.Init = printString(td.PrintFileInitContent)
+
+	if d, ok := td.(interface{ PrintDocLink(io.Writer) }); ok {
+		rval.DocLink = printString(d.PrintDocLink)
+	}
+
+	for _, v := range td.AllValues() {
+		rval.Values = append(rval.Values, newValueData(v))
+	}
+
+	switch t := td.(type) {
+	case *handleType:
+		rval.setInternalType(&t.internalType)
+	case *bitmaskType:
+		rval.setInternalType(&t.internalType)
+	case *enumType:
+		rval.setInternalType(&t.internalType)
+		rval.IsBitmaskEnum = t.bitmask() != nil
+		rval.Bitwidth = t.bitwidth
+		rval.Methods = printString(t.printMethods)
+	case *structType:
+		rval.Comment = t.comment
+		rval.setAlias(&t.genericType)
+		rval.Members = newMemberData(t.members, false)
+	case *unionType:
+		rval.Comment = t.comment
+		rval.Members = newMemberData(t.members, true)
+	case *commandType:
+		rval.Comment = t.comment
+		rval.setAlias(&t.genericType)
+		rval.StaticCodeRef = t.staticCodeRef
+		rval.ParamList, rval.ResultList, rval.Body = t.paramList, t.resultList, t.body
+		rval.Params = newParamData(t.inputParams)
+		rval.Results = newParamData(t.resultParams)
+		rval.BindingParamCount = t.bindingParamCount
+		rval.HasReturn = t.resolvedReturnType != nil
+	}
+
+	return rval
+}
+
+func (d *TypeData) setAlias(t *genericType) {
+	if t.IsAlias() && t.resolvedAliasType != nil {
+		d.AliasOf = t.resolvedAliasType.PublicName()
+	}
+}
+
+func (d *TypeData) setInternalType(t *internalType) {
+	d.Comment = t.comment
+	d.setAlias(&t.genericType)
+	if t.underlyingType != nil {
+		d.Underlying = t.underlyingType.PublicName()
+	}
+}
+
+func newValueData(v ValueDefiner) ValueData {
+	rval := ValueData{
+		RegistryName: v.RegistryName(),
+		PublicName:   v.PublicName(),
+		Value:        v.ValueString(),
+		Deprecated:   v.DeprecationNote(),
+		IsAlias:      v.IsAlias(),
+		Declaration:  printString(v.PrintPublicDeclaration),
+	}
+	if v.ResolvedType() != nil {
+		rval.Type = v.ResolvedType().PublicName()
+	}
+	if ev, ok := v.(*enumValue); ok {
+		rval.Comment = ev.comment
+	}
+	return rval
+}
+
+func newMemberData(members []*structMember, forUnion bool) []MemberData {
+	rval := make([]MemberData, 0, len(members))
+	for _, m := range members {
+		md := MemberData{
+			RegistryName: m.registryName,
+			PublicName:   m.PublicName(),
+			InternalName: m.InternalName(),
+			PublicType:   m.resolvedType.PublicName(),
+			InternalType: m.resolvedType.InternalName(),
+			Comment:      m.comment,
+			Hidden:       (m.resolvedValue != nil || m.isLenForOtherMember != nil || m.hidden) && !m.forceInclude,
+			Declaration:  printString(m.PrintPublicDeclaration),
+		}
+
+		if forUnion {
+			// Same rules as unionType.PrintPublicDeclaration
+			md.UnionSetter = "value"
+			if m.pointerDepth > 0 && m.resolvedType.PublicName() != "string" {
+				if m.resolvedType.PublicName() == "unsafe.Pointer" || m.resolvedType.Category() == CatPointer {
+					md.UnionSetter = "pointer"
+				} else {
+					md.UnionSetter = "slice"
+				}
+			}
+		}
+
+		rval = append(rval, md)
+	}
+	return rval
+}
+
+func newParamData(params []*commandParam) []ParamData {
+	rval := make([]ParamData, 0, len(params))
+	for _, p := range params {
+		rval = append(rval, ParamData{Name: p.publicName, Type: p.class.publicType.PublicName(), Kind: string(p.class.kind)})
+	}
+	return rval
+}
+
+func printString(print func(io.Writer)) string {
+	sb := &strings.Builder{}
+	print(sb)
+	return sb.String()
+}
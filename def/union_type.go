@@ -73,6 +73,9 @@ func (t *unionType) PrintPublicDeclaration(w io.Writer) {
 	}
 }
 
+// RegisterImports adds unsafe, which the internal union and Vulkanize refer to
+func (t *unionType) RegisterImports(reg map[string]bool) { reg["unsafe"] = true }
+
 func (t *unionType) PrintInternalDeclaration(w io.Writer) {
 
 	var preamble, structDecl, epilogue strings.Builder
@@ -131,7 +134,7 @@ func (t *unionType) TranslateToInternal(inputVar string) string {
 }
 
 func ReadUnionTypesFromXML(doc *xmlquery.Node, tr TypeRegistry, vr ValueRegistry, api string) {
-	queryString := fmt.Sprintf("//types/type[@category='union' and (@api='%s' or not(@api))]", api)
+	queryString := fmt.Sprintf("//types/type[@category='union' and %s]", ApiPredicate("api", api))
 
 	for _, node := range xmlquery.Find(doc, queryString) {
 		s := newUnionTypeFromXML(node, api)
@@ -145,7 +148,7 @@ func newUnionTypeFromXML(node *xmlquery.Node, api string) *unionType {
 	rval.registryName = node.SelectAttr("name")
 	rval.isReturnedOnly = node.SelectAttr("returnedonly") == "true"
 
-	queryString := fmt.Sprintf("member[@api='%s' or not(@api)]", api)
+	queryString := fmt.Sprintf("member[%s]", ApiPredicate("api", api))
 	for _, mNode := range xmlquery.Find(node, queryString) {
 		rval.members = append(rval.members, newStructMemberFromXML(mNode))
 	}
@@ -0,0 +1,189 @@
+package feat
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// DependsExpr is a parsed dependency expression, as found in the depends attribute of <extension>, <feature> and
+// <require> elements. The registry grammar allows extension and version names, combined with "+" (logical AND) and
+// "," (logical OR), and grouped with parentheses. "+" binds more tightly than ",".
+type DependsExpr interface {
+	// Eval reports whether the expression is satisfied, given a function reporting whether a single extension or
+	// version name is available in the generation set.
+	Eval(available func(name string) bool) bool
+	// Names returns every extension or version name referenced by the expression.
+	Names() []string
+
+	String() string
+}
+
+type dependsName string
+
+func (n dependsName) Eval(available func(string) bool) bool { return available(string(n)) }
+func (n dependsName) Names() []string                       { return []string{string(n)} }
+func (n dependsName) String() string                        { return string(n) }
+
+type dependsAnd []DependsExpr
+
+func (a dependsAnd) Eval(available func(string) bool) bool {
+	for _, e := range a {
+		if !e.Eval(available) {
+			return false
+		}
+	}
+	return true
+}
+func (a dependsAnd) Names() []string { return collectNames(a) }
+func (a dependsAnd) String() string  { return joinExprs(a, "+") }
+
+type dependsOr []DependsExpr
+
+func (o dependsOr) Eval(available func(string) bool) bool {
+	for _, e := range o {
+		if e.Eval(available) {
+			return true
+		}
+	}
+	return false
+}
+func (o dependsOr) Names() []string { return collectNames(o) }
+func (o dependsOr) String() string  { return joinExprs(o, ",") }
+
+func collectNames(exprs []DependsExpr) []string {
+	seen := make(map[string]bool)
+	for _, e := range exprs {
+		for _, n := range e.Names() {
+			seen[n] = true
+		}
+	}
+	rval := make([]string, 0, len(seen))
+	for n := range seen {
+		rval = append(rval, n)
+	}
+	sort.Strings(rval)
+	return rval
+}
+
+func joinExprs(exprs []DependsExpr, op string) string {
+	parts := make([]string, len(exprs))
+	for i, e := range exprs {
+		switch e.(type) {
+		case dependsName:
+			parts[i] = e.String()
+		default:
+			parts[i] = "(" + e.String() + ")"
+		}
+	}
+	return strings.Join(parts, op)
+}
+
+// ParseDepends parses a dependency expression. An empty string returns a nil expression, which callers should treat
+// as always satisfied.
+func ParseDepends(s string) (DependsExpr, error) {
+	if strings.TrimSpace(s) == "" {
+		return nil, nil
+	}
+
+	p := dependsParser{input: s}
+	expr, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	p.skipSpace()
+	if p.pos < len(p.input) {
+		return nil, fmt.Errorf("unexpected %q at position %d in dependency expression %q", p.input[p.pos], p.pos, s)
+	}
+	return expr, nil
+}
+
+type dependsParser struct {
+	input string
+	pos   int
+}
+
+func (p *dependsParser) skipSpace() {
+	for p.pos < len(p.input) && (p.input[p.pos] == ' ' || p.input[p.pos] == '\t' || p.input[p.pos] == '\n') {
+		p.pos++
+	}
+}
+
+func (p *dependsParser) peek() byte {
+	p.skipSpace()
+	if p.pos < len(p.input) {
+		return p.input[p.pos]
+	}
+	return 0
+}
+
+func (p *dependsParser) parseOr() (DependsExpr, error) {
+	terms := dependsOr{}
+	for {
+		t, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		terms = append(terms, t)
+		if p.peek() != ',' {
+			break
+		}
+		p.pos++
+	}
+	if len(terms) == 1 {
+		return terms[0], nil
+	}
+	return terms, nil
+}
+
+func (p *dependsParser) parseAnd() (DependsExpr, error) {
+	terms := dependsAnd{}
+	for {
+		t, err := p.parsePrimary()
+		if err != nil {
+			return nil, err
+		}
+		terms = append(terms, t)
+		if p.peek() != '+' {
+			break
+		}
+		p.pos++
+	}
+	if len(terms) == 1 {
+		return terms[0], nil
+	}
+	return terms, nil
+}
+
+func (p *dependsParser) parsePrimary() (DependsExpr, error) {
+	switch c := p.peek(); {
+	case c == '(':
+		p.pos++
+		e, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if p.peek() != ')' {
+			return nil, fmt.Errorf("missing ')' at position %d in dependency expression %q", p.pos, p.input)
+		}
+		p.pos++
+		return e, nil
+
+	case isNameChar(c):
+		start := p.pos
+		for p.pos < len(p.input) && isNameChar(p.input[p.pos]) {
+			p.pos++
+		}
+		return dependsName(p.input[start:p.pos]), nil
+
+	case c == 0:
+		return nil, fmt.Errorf("unexpected end of dependency expression %q", p.input)
+
+	default:
+		return nil, fmt.Errorf("unexpected %q at position %d in dependency expression %q", c, p.pos, p.input)
+	}
+}
+
+func isNameChar(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9')
+}
@@ -0,0 +1,71 @@
+package feat
+
+import (
+	"reflect"
+	"testing"
+)
+
+var dependsCases = []struct {
+	expr, str string
+	names     []string
+	// Result of Eval when only VK_A and VK_VERSION_1_1 are available
+	eval bool
+}{
+	{"VK_A", "VK_A", []string{"VK_A"}, true},
+	{"VK_A+VK_B,VK_C", "(VK_A+VK_B),VK_C", []string{"VK_A", "VK_B", "VK_C"}, false},
+	{"VK_A,VK_B+VK_C", "VK_A,(VK_B+VK_C)", []string{"VK_A", "VK_B", "VK_C"}, true},
+	{"VK_B+VK_C,VK_A", "(VK_B+VK_C),VK_A", []string{"VK_A", "VK_B", "VK_C"}, true},
+	{"(VK_A,VK_B)+VK_C", "(VK_A,VK_B)+VK_C", []string{"VK_A", "VK_B", "VK_C"}, false},
+	{"((VK_A,VK_B)+(VK_C,VK_VERSION_1_1))", "(VK_A,VK_B)+(VK_C,VK_VERSION_1_1)", []string{"VK_A", "VK_B", "VK_C", "VK_VERSION_1_1"}, true},
+	{"VK_VERSION_1_1+VK_A", "VK_VERSION_1_1+VK_A", []string{"VK_A", "VK_VERSION_1_1"}, true},
+	{"VK_VERSION_1_2,VK_B", "VK_VERSION_1_2,VK_B", []string{"VK_B", "VK_VERSION_1_2"}, false},
+	{" VK_A + VK_B ", "VK_A+VK_B", []string{"VK_A", "VK_B"}, false},
+}
+
+func TestParseDepends(t *testing.T) {
+	available := func(name string) bool { return name == "VK_A" || name == "VK_VERSION_1_1" }
+
+	for _, c := range dependsCases {
+		expr, err := ParseDepends(c.expr)
+		if err != nil {
+			t.Errorf("ParseDepends(%q) returned an error: %v", c.expr, err)
+			continue
+		}
+		if got := expr.String(); got != c.str {
+			t.Errorf("ParseDepends(%q).String() = %q, want %q", c.expr, got, c.str)
+		}
+		if got := expr.Names(); !reflect.DeepEqual(got, c.names) {
+			t.Errorf("ParseDepends(%q).Names() = %v, want %v", c.expr, got, c.names)
+		}
+		if got := expr.Eval(available); got != c.eval {
+			t.Errorf("ParseDepends(%q).Eval() = %v, want %v", c.expr, got, c.eval)
+		}
+	}
+}
+
+func TestParseDependsEmpty(t *testing.T) {
+	for _, s := range []string{"", "  "} {
+		if expr, err := ParseDepends(s); expr != nil || err != nil {
+			t.Errorf("ParseDepends(%q) = %v, %v; want nil, nil", s, expr, err)
+		}
+	}
+}
+
+func TestParseDependsMalformed(t *testing.T) {
+	for _, s := range []string{
+		"VK_A,,VK_B", // Empty operand
+		",VK_A",
+		"VK_A+(VK_B,)",
+		"(VK_A,VK_B", // Unbalanced parentheses
+		"((VK_A)",
+		"VK_A)",
+		"VK_A+", // Trailing operator
+		"VK_A,",
+		"VK_A VK_B",
+		"VK_A-VK_B",
+	} {
+		if expr, err := ParseDepends(s); err == nil {
+			t.Errorf("ParseDepends(%q) = %v, want an error", s, expr)
+		}
+	}
+}
@@ -1,72 +1,179 @@
 package feat
 
 import (
+	"fmt"
+	"regexp"
+	"sort"
 	"strconv"
+	"strings"
 
 	"github.com/antchfx/xmlquery"
 	"github.com/bbredesen/vk-gen/def"
+	"github.com/sirupsen/logrus"
 )
 
 type Extension struct {
 	extensionName                   string
 	extensionNumber                 string
 	supportedString, platformString string
+	dependsString                   string
+	depends                         DependsExpr
+
+	deprecatedBy, obsoletedBy, promotedTo string
+	isDeprecated                          bool
 
 	*Feature
 
 	requireExtensionNames map[string]bool
 }
 
-func ReadExtensionFromXML(extNode *xmlquery.Node, tr def.TypeRegistry, vr def.ValueRegistry) *Extension {
+func ReadExtensionFromXML(extNode *xmlquery.Node, tr def.TypeRegistry, vr def.ValueRegistry, api string) (*Extension, error) {
 	rval := Extension{
 		extensionName:         extNode.SelectAttr("name"),
 		extensionNumber:       extNode.SelectAttr("number"),
 		supportedString:       extNode.SelectAttr("supported"),
 		platformString:        extNode.SelectAttr("platform"),
+		dependsString:         extNode.SelectAttr("depends"),
 		requireExtensionNames: make(map[string]bool),
 		Feature:               NewFeature(),
 	}
-
-	for _, reqNode := range xmlquery.Find(extNode, "/require") {
-		for _, typeNode := range xmlquery.Find(reqNode, "/type") { //} or /command") {
-			rval.requireTypeNames[typeNode.SelectAttr("name")] = true
+	rval.featureName = rval.extensionName
+	rval.targetApi = api
+
+	// deprecatedby may be present, but empty, for an extension deprecated without replacement
+	for _, attr := range extNode.Attr {
+		switch attr.Name.Local {
+		case "deprecatedby":
+			rval.isDeprecated = true
+			rval.deprecatedBy = attr.Value
+		case "obsoletedby":
+			rval.obsoletedBy = attr.Value
+		case "promotedto":
+			rval.promotedTo = attr.Value
 		}
+	}
+
+	if rval.dependsString == "" {
+		// Registries prior to 1.3.241 list dependencies in the requires attribute instead, where a comma means that
+		// all of the listed extensions are required.
+		rval.dependsString = strings.ReplaceAll(extNode.SelectAttr("requires"), ",", "+")
+	}
 
-		for _, cmdNode := range xmlquery.Find(reqNode, "/command") {
-			rval.requireTypeNames[cmdNode.SelectAttr("name")] = true
+	var err error
+	if rval.depends, err = ParseDepends(rval.dependsString); err != nil {
+		logrus.WithField("extension", rval.extensionName).
+			WithField("depends", rval.dependsString).
+			WithError(err).
+			Error("could not parse extension dependencies; dependencies will be ignored")
+	} else if rval.depends != nil {
+		for _, name := range rval.depends.Names() {
+			if !isVersionName(name) {
+				rval.requireExtensionNames[name] = true
+			}
 		}
+	}
 
-		for _, enumNode := range xmlquery.Find(reqNode, "/enum") {
-			extendsTypeName := enumNode.SelectAttr("extends")
+	extNum, err := strconv.Atoi(rval.extensionNumber)
+	if err != nil {
+		return nil, fmt.Errorf("extension %s has an invalid number: %w", rval.extensionName, err)
+	}
 
-			if extendsTypeName == "" && enumNode.SelectAttr("value") == "" && enumNode.SelectAttr("alias") == "" {
-				// Some extensions are actually requiring an outside constant, like VK_SHADER_UNUSED_KHR; These
-				// should already be in the registry as external types
-				rval.requireValueNames[enumNode.SelectAttr("name")] = true
-				continue
-			}
+	for _, reqNode := range xmlquery.Find(extNode, fmt.Sprintf("/require[%s]", def.ApiPredicate("api", api))) {
+		rval.readRequireNode(reqNode, extNum, tr, vr)
+	}
+	for _, remNode := range xmlquery.Find(extNode, fmt.Sprintf("/remove[%s]", def.ApiPredicate("api", api))) {
+		rval.readRemoveNode(remNode)
+	}
 
-			extNum, err := strconv.Atoi(rval.extensionNumber)
-			if err != nil {
-				panic(err)
-			}
+	return &rval, nil
+}
 
-			var vd def.ValueDefiner
+// isVersionName returns true if name refers to a core version feature (VK_VERSION_1_1, VKSC_VERSION_1_0, etc.)
+// instead of an extension.
+func isVersionName(name string) bool {
+	return rxVersionName.MatchString(name)
+}
 
-			if td, found := tr[extendsTypeName]; found {
-				vd = def.NewEnumValueFromXML(td, enumNode)
-			} else {
-				vd = def.NewUntypedEnumValueFromXML(enumNode)
-			}
-			vd.SetExtensionNumber(extNum)
-			vr[vd.RegistryName()] = vd
+var rxVersionName = regexp.MustCompile(`^VK\w*_VERSION_\d+_\d+$`)
 
-			rval.requireValueNames[enumNode.SelectAttr("name")] = true
-		}
+func (e *Extension) Name() string         { return e.extensionName }
+func (e *Extension) PlatformName() string { return e.platformString }
+
+// Depends returns the extension's parsed dependency expression, or nil if the extension has no dependencies.
+func (e *Extension) Depends() DependsExpr { return e.depends }
+
+// RequiredExtensionNames returns the names of all other extensions referenced by this extension's dependency
+// expression. Core version dependencies are not included. Note that the expression may allow alternatives, so not
+// every name returned is necessarily required.
+func (e *Extension) RequiredExtensionNames() []string {
+	rval := make([]string, 0, len(e.requireExtensionNames))
+	for k := range e.requireExtensionNames {
+		rval = append(rval, k)
 	}
+	sort.Strings(rval)
+	return rval
+}
 
-	return &rval
+// DeprecationNote returns the reason this extension should no longer be used, or an empty string if it is still
+// current. Promotion is only considered a deprecation if the promoted version or extension is available in the
+// generation set.
+func (e *Extension) DeprecationNote(isAvailable func(string) bool) string {
+	switch {
+	case e.obsoletedBy != "":
+		return fmt.Sprintf("%s has been obsoleted by %s", e.extensionName, e.obsoletedBy)
+	case e.isDeprecated && e.deprecatedBy != "":
+		return fmt.Sprintf("%s has been deprecated by %s", e.extensionName, e.deprecatedBy)
+	case e.isDeprecated:
+		return fmt.Sprintf("%s has been deprecated without replacement", e.extensionName)
+	case e.promotedTo != "" && isAvailable(e.promotedTo):
+		return fmt.Sprintf("%s was promoted to %s", e.extensionName, e.promotedTo)
+	}
+	return ""
 }
 
-func (e *Extension) Name() string         { return e.extensionName }
-func (e *Extension) PlatformName() string { return e.platformString }
+// MarkDeprecatedExtensions sets a deprecation note on the types, commands and enum values that are only required by
+// deprecated, obsoleted or promoted extensions. Anything also required by the core feature or by a current
+// extension is left alone. Extension name and spec version constants are never marked, since they are needed to
+// enable the extension.
+func MarkDeprecatedExtensions(core *Feature, exts map[string]*Extension, isAvailable func(string) bool, tr def.TypeRegistry, vr def.ValueRegistry) {
+	keepTypes, keepValues := make(map[string]bool), make(map[string]bool)
+	for k := range core.requireTypeNames {
+		keepTypes[k] = true
+	}
+	for k := range core.requireValueNames {
+		keepValues[k] = true
+	}
+
+	names := make([]string, 0, len(exts))
+	for k, ext := range exts {
+		names = append(names, k)
+		if ext.DeprecationNote(isAvailable) == "" {
+			for k := range ext.requireTypeNames {
+				keepTypes[k] = true
+			}
+			for k := range ext.requireValueNames {
+				keepValues[k] = true
+			}
+		}
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		ext := exts[name]
+		note := ext.DeprecationNote(isAvailable)
+		if note == "" {
+			continue
+		}
+
+		for k := range ext.requireTypeNames {
+			if td, found := tr[k]; found && !keepTypes[k] {
+				td.SetDeprecationNote(note)
+			}
+		}
+		for k := range ext.requireValueNames {
+			if vd, found := vr[k]; found && !keepValues[k] && vd.UnderlyingTypeName() != "" {
+				vd.SetDeprecationNote(note)
+			}
+		}
+	}
+}
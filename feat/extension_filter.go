@@ -0,0 +1,187 @@
+package feat
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+)
+
+// ExtensionFilter selects the extensions to generate. Entries in the include and exclude lists are either full
+// extension names (VK_KHR_swapchain) or vendor tags (KHR, NV, AMDX), which match every extension with that tag.
+type ExtensionFilter struct {
+	IncludeNames, ExcludeNames     map[string]bool
+	IncludeVendors, ExcludeVendors map[string]bool
+}
+
+// NewExtensionFilter sorts each entry of the include and exclude lists into a name or a vendor tag. Anything
+// starting with "VK" is treated as an extension name.
+func NewExtensionFilter(includes, excludes []string) *ExtensionFilter {
+	rval := ExtensionFilter{
+		IncludeNames:   make(map[string]bool),
+		ExcludeNames:   make(map[string]bool),
+		IncludeVendors: make(map[string]bool),
+		ExcludeVendors: make(map[string]bool),
+	}
+
+	sortEntries := func(entries []string, names, vendors map[string]bool) {
+		for _, e := range entries {
+			e = strings.TrimSpace(e)
+			if e == "" {
+				continue
+			}
+			if strings.HasPrefix(e, "VK") {
+				names[e] = true
+			} else {
+				vendors[e] = true
+			}
+		}
+	}
+	sortEntries(includes, rval.IncludeNames, rval.IncludeVendors)
+	sortEntries(excludes, rval.ExcludeNames, rval.ExcludeVendors)
+
+	return &rval
+}
+
+// VendorOf returns the vendor tag embedded in an extension name, e.g. "NV" for VK_NV_mesh_shader.
+func VendorOf(extensionName string) string {
+	parts := strings.SplitN(extensionName, "_", 3)
+	if len(parts) < 3 {
+		return ""
+	}
+	return parts[1]
+}
+
+// Allows reports whether the named extension passes the filter, before any dependencies are considered. Names take
+// precedence over vendor tags, and exclusions take precedence over inclusions. When no include list is provided,
+// every extension that is not excluded is allowed.
+func (f *ExtensionFilter) Allows(extensionName string) bool {
+	if f.ExcludeNames[extensionName] {
+		return false
+	}
+	if f.IncludeNames[extensionName] {
+		return true
+	}
+
+	vendor := VendorOf(extensionName)
+	if f.ExcludeVendors[vendor] {
+		return false
+	}
+	if len(f.IncludeNames) == 0 && len(f.IncludeVendors) == 0 {
+		return true
+	}
+	return f.IncludeVendors[vendor]
+}
+
+// SelectExtensions applies the filter to the candidate extensions and then adds whatever is needed to satisfy each
+// selected extension's dependency expression, so that the resulting set is self-consistent. Dependencies are added
+// even if the filter would otherwise exclude them. Where an expression allows alternatives, an alternative that is
+// already selected is preferred; otherwise the first alternative that can be satisfied is added. coreVersions holds
+// the names of the core version features being generated (VK_VERSION_1_0, etc.).
+//
+// An extension is dropped if its dependencies cannot be satisfied from the candidates and core versions (e.g., it
+// depends on an extension of a platform that is not being generated, or on a later core version).
+func SelectExtensions(candidates map[string]*Extension, filter *ExtensionFilter, coreVersions map[string]bool) map[string]*Extension {
+	selected := make(map[string]*Extension)
+
+	names := make([]string, 0, len(candidates))
+	for k := range candidates {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		if filter.Allows(name) {
+			selected[name] = candidates[name]
+		}
+	}
+
+	isSelected := func(name string) bool {
+		if isVersionName(name) {
+			return coreVersions[name]
+		}
+		return selected[name] != nil
+	}
+	isCandidate := func(name string) bool {
+		if isVersionName(name) {
+			return coreVersions[name]
+		}
+		return candidates[name] != nil
+	}
+
+	// Dependency closure
+	worklist := make([]string, 0, len(selected))
+	for _, name := range names {
+		if selected[name] != nil {
+			worklist = append(worklist, name)
+		}
+	}
+
+	var satisfy func(expr DependsExpr, requiredBy string) bool
+	satisfy = func(expr DependsExpr, requiredBy string) bool {
+		switch e := expr.(type) {
+		case dependsName:
+			name := string(e)
+			if isSelected(name) {
+				return true
+			}
+			if !isCandidate(name) {
+				return false
+			}
+			logrus.WithField("extension", name).
+				WithField("required by", requiredBy).
+				Info("Including extension as a dependency")
+			selected[name] = candidates[name]
+			worklist = append(worklist, name)
+			return true
+
+		case dependsAnd:
+			rval := true
+			for _, term := range e {
+				rval = satisfy(term, requiredBy) && rval
+			}
+			return rval
+
+		case dependsOr:
+			if e.Eval(isSelected) {
+				return true
+			}
+			for _, term := range e {
+				if term.Eval(isCandidate) {
+					return satisfy(term, requiredBy)
+				}
+			}
+			return false
+		}
+		return false
+	}
+
+	for len(worklist) > 0 {
+		ext := selected[worklist[0]]
+		worklist = worklist[1:]
+
+		if ext.depends != nil {
+			satisfy(ext.depends, ext.Name())
+		}
+	}
+
+	// Drop anything with unsatisfied dependencies, repeating until nothing else is removed
+	for removed := true; removed; {
+		removed = false
+		for _, name := range names {
+			ext := selected[name]
+			if ext == nil || ext.depends == nil {
+				continue
+			}
+			if !ext.depends.Eval(isSelected) {
+				logrus.WithField("extension", name).
+					WithField("depends", ext.depends.String()).
+					Warn("Dropping extension with unsatisfied dependencies")
+				delete(selected, name)
+				removed = true
+			}
+		}
+	}
+
+	return selected
+}
@@ -0,0 +1,137 @@
+package feat
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+)
+
+// testExtensions returns candidate extensions with the given depends attributes
+func testExtensions(t *testing.T, depends map[string]string) map[string]*Extension {
+	t.Helper()
+
+	rval := make(map[string]*Extension)
+	for name, d := range depends {
+		expr, err := ParseDepends(d)
+		if err != nil {
+			t.Fatal(err)
+		}
+		rval[name] = &Extension{extensionName: name, dependsString: d, depends: expr}
+	}
+	return rval
+}
+
+func TestSelectExtensions(t *testing.T) {
+	candidates := testExtensions(t, map[string]string{
+		"VK_KHR_surface":         "",
+		"VK_KHR_swapchain":       "VK_KHR_surface",
+		"VK_KHR_display":         "VK_KHR_surface",
+		"VK_KHR_win32_surface":   "VK_KHR_surface",
+		"VK_KHR_get_props":       "",
+		"VK_NV_mesh":             "VK_KHR_get_props,VK_VERSION_1_1",
+		"VK_AMD_both":            "VK_KHR_get_props+VK_KHR_display",
+		"VK_EXT_late":            "VK_VERSION_1_3",
+		"VK_EXT_needs_missing":   "VK_KHR_missing",
+		"VK_EXT_chain":           "VK_EXT_needs_missing",
+		"VK_EXT_either_platform": "VK_KHR_missing,VK_KHR_win32_surface",
+	})
+	core10 := map[string]bool{"VK_VERSION_1_0": true}
+	core11 := map[string]bool{"VK_VERSION_1_0": true, "VK_VERSION_1_1": true}
+
+	cases := []struct {
+		name               string
+		includes, excludes []string
+		coreVersions       map[string]bool
+		want               []string
+	}{
+		{
+			"no filter drops unsatisfiable extensions", nil, nil, core11,
+			[]string{"VK_AMD_both", "VK_EXT_either_platform", "VK_KHR_display", "VK_KHR_get_props", "VK_KHR_surface",
+				"VK_KHR_swapchain", "VK_KHR_win32_surface", "VK_NV_mesh"},
+		},
+		{
+			"dependencies are included", []string{"VK_KHR_swapchain"}, nil, core10,
+			[]string{"VK_KHR_surface", "VK_KHR_swapchain"},
+		},
+		{
+			"dependencies are included in a chain", []string{"VK_AMD_both"}, nil, core10,
+			[]string{"VK_AMD_both", "VK_KHR_display", "VK_KHR_get_props", "VK_KHR_surface"},
+		},
+		{
+			"an exclusion does not apply to a dependency", []string{"VK_KHR_swapchain"}, []string{"VK_KHR_surface"}, core10,
+			[]string{"VK_KHR_surface", "VK_KHR_swapchain"},
+		},
+		{
+			"an excluded extension is not included by itself", []string{"KHR"}, []string{"VK_KHR_swapchain", "VK_KHR_display"}, core10,
+			[]string{"VK_KHR_get_props", "VK_KHR_surface", "VK_KHR_win32_surface"},
+		},
+		{
+			"an included name takes precedence over an excluded vendor", []string{"VK_KHR_swapchain"}, []string{"KHR"}, core10,
+			[]string{"VK_KHR_surface", "VK_KHR_swapchain"},
+		},
+		{
+			"an excluded vendor drops only its own extensions", nil, []string{"KHR", "EXT", "AMD"}, core10,
+			[]string{"VK_KHR_get_props", "VK_NV_mesh"},
+		},
+		{
+			"the first satisfiable alternative is added", []string{"NV"}, nil, core10,
+			[]string{"VK_KHR_get_props", "VK_NV_mesh"},
+		},
+		{
+			"a selected core version satisfies an alternative", []string{"NV"}, nil, core11,
+			[]string{"VK_NV_mesh"},
+		},
+		{
+			"an alternative that is not a candidate is skipped", []string{"VK_EXT_either_platform"}, nil, core10,
+			[]string{"VK_EXT_either_platform", "VK_KHR_surface", "VK_KHR_win32_surface"},
+		},
+		{
+			"a later core version cannot be satisfied", []string{"VK_EXT_late"}, nil, core11,
+			[]string{},
+		},
+		{
+			"extensions depending on dropped extensions are dropped", []string{"VK_EXT_chain", "VK_KHR_surface"}, nil, core10,
+			[]string{"VK_KHR_surface"},
+		},
+	}
+
+	for _, c := range cases {
+		selected := SelectExtensions(candidates, NewExtensionFilter(c.includes, c.excludes), c.coreVersions)
+
+		got := make([]string, 0, len(selected))
+		for name := range selected {
+			got = append(got, name)
+		}
+		sort.Strings(got)
+		if !reflect.DeepEqual(got, c.want) {
+			t.Errorf("%s: selected %v, want %v", c.name, got, c.want)
+		}
+	}
+}
+
+func TestExtensionFilterAllows(t *testing.T) {
+	cases := []struct {
+		includes, excludes []string
+		name               string
+		want               bool
+	}{
+		{nil, nil, "VK_KHR_surface", true},
+		{[]string{"KHR"}, nil, "VK_KHR_surface", true},
+		{[]string{"KHR"}, nil, "VK_NV_mesh", false},
+		{[]string{"AMDX"}, nil, "VK_AMD_thing", false},
+		{[]string{"AMDX"}, nil, "VK_AMDX_thing", true},
+		{[]string{" VK_NV_mesh "}, nil, "VK_NV_mesh", true},
+		{[]string{"VK_NV_mesh"}, nil, "VK_NV_other", false},
+		{nil, []string{"NV"}, "VK_NV_mesh", false},
+		{nil, []string{"NV"}, "VK_KHR_surface", true},
+		{[]string{"NV"}, []string{"VK_NV_mesh"}, "VK_NV_mesh", false},
+		{[]string{"VK_NV_mesh"}, []string{"NV"}, "VK_NV_mesh", true},
+		{[]string{"VK_NV_mesh"}, []string{"VK_NV_mesh"}, "VK_NV_mesh", false},
+	}
+
+	for _, c := range cases {
+		if got := NewExtensionFilter(c.includes, c.excludes).Allows(c.name); got != c.want {
+			t.Errorf("include %v, exclude %v: Allows(%q) = %v, want %v", c.includes, c.excludes, c.name, got, c.want)
+		}
+	}
+}
@@ -1,17 +1,37 @@
 package feat
 
 import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
 	"github.com/antchfx/xmlquery"
 	"github.com/bbredesen/vk-gen/def"
+	"github.com/sirupsen/logrus"
 )
 
 type Feature struct {
 	apiName, featureName string
-	version              string
+
+	// targetApi is the API being generated; require blocks and their elements tagged for other APIs are skipped
+	targetApi string
+	version   string
 
 	requireTypeNames, requireValueNames map[string]bool
 	ResolvedTypes                       def.TypeRegistry
 	ResolvedValues                      map[string]def.ValueRegistry
+
+	conditionalRequires []conditionalRequire
+
+	removeTypeNames, removeValueNames map[string]bool
+	deprecationNotes                  map[string]string
+}
+
+// conditionalRequire holds the contents of a <require> block with a depends attribute
+type conditionalRequire struct {
+	depends DependsExpr
+	*Feature
 }
 
 func NewFeature() *Feature {
@@ -20,6 +40,9 @@ func NewFeature() *Feature {
 		requireValueNames: make(map[string]bool),
 		ResolvedTypes:     make(def.TypeRegistry),
 		ResolvedValues:    make(map[string]def.ValueRegistry),
+		removeTypeNames:   make(map[string]bool),
+		removeValueNames:  make(map[string]bool),
+		deprecationNotes:  make(map[string]string),
 	}
 
 }
@@ -50,19 +73,36 @@ func (f *Feature) MergeIncludeSet(is *def.IncludeSet) {
 
 }
 
+// Resolve resolves every required type and value. Names are resolved in sorted order, so that any side effects of
+// resolution (e.g. which feature first includes a shared type) are the same on every run.
 func (f *Feature) Resolve(tr def.TypeRegistry, vr def.ValueRegistry) {
-	for k := range f.requireTypeNames {
-		f.MergeIncludeSet(tr[k].Resolve(tr, vr))
+	for _, k := range sortedNames(f.requireTypeNames) {
+		td, found := tr[k]
+		if !found {
+			// e.g., VK_VERSION_1_0 requires VK_API_VERSION_1_0, which is only defined for the vulkan API
+			logrus.WithField("feature", f.featureName).
+				WithField("registry name", k).
+				Warn("required type or command is not defined for the target API; skipping")
+			continue
+		}
+		f.MergeIncludeSet(td.Resolve(tr, vr))
 	}
 
+	// Every core value of a resolved type is generated, unless a <remove> block drops it
 	for k, v := range vr {
-		if v.IsCore() && f.ResolvedTypes[vr[k].UnderlyingTypeName()] != nil {
+		if v.IsCore() && !f.removeValueNames[k] && f.ResolvedTypes[vr[k].UnderlyingTypeName()] != nil {
 			f.requireValueNames[k] = true
 		}
 	}
 
-	for k := range f.requireValueNames {
-		val := vr[k]
+	for _, k := range sortedNames(f.requireValueNames) {
+		val, found := vr[k]
+		if !found {
+			logrus.WithField("feature", f.featureName).
+				WithField("registry name", k).
+				Warn("required value is not defined for the target API; skipping")
+			continue
+		}
 		f.MergeIncludeSet(val.Resolve(tr, vr))
 
 		resVals, found := f.ResolvedValues[val.UnderlyingTypeName()]
@@ -74,6 +114,25 @@ func (f *Feature) Resolve(tr def.TypeRegistry, vr def.ValueRegistry) {
 	}
 }
 
+func sortedNames(m map[string]bool) []string {
+	rval := make([]string, 0, len(m))
+	for k := range m {
+		rval = append(rval, k)
+	}
+	sort.Strings(rval)
+	return rval
+}
+
+// SortedCategories returns the keys of a map returned by FilterByCategory, in TypeCategory order
+func SortedCategories(m map[def.TypeCategory]*Feature) []def.TypeCategory {
+	rval := make([]def.TypeCategory, 0, len(m))
+	for k := range m {
+		rval = append(rval, k)
+	}
+	sort.Slice(rval, func(i, j int) bool { return rval[i] < rval[j] })
+	return rval
+}
+
 func (f *Feature) FilterByCategory() map[def.TypeCategory]*Feature {
 	rval := make(map[def.TypeCategory]*Feature)
 
@@ -119,41 +178,146 @@ func (f *Feature) FilterByCategory() map[def.TypeCategory]*Feature {
 	return rval
 }
 
-func ReadFeatureFromXML(featureNode *xmlquery.Node, tr def.TypeRegistry, vr def.ValueRegistry) *Feature {
+func ReadFeatureFromXML(featureNode *xmlquery.Node, tr def.TypeRegistry, vr def.ValueRegistry, api string) *Feature {
 	rval := NewFeature()
 	rval.apiName = featureNode.SelectAttr("api")
+	rval.targetApi = api
 	rval.featureName = featureNode.SelectAttr("name")
 	rval.version = featureNode.SelectAttr("number")
 
-	for _, reqNode := range xmlquery.Find(featureNode, "/require") {
-		for _, typeNode := range xmlquery.Find(reqNode, "/type") {
-			rval.requireTypeNames[typeNode.SelectAttr("name")] = true
+	for _, reqNode := range xmlquery.Find(featureNode, fmt.Sprintf("/require[%s]", def.ApiPredicate("api", api))) {
+		rval.readRequireNode(reqNode, 0, tr, vr)
+	}
+	for _, remNode := range xmlquery.Find(featureNode, fmt.Sprintf("/remove[%s]", def.ApiPredicate("api", api))) {
+		rval.readRemoveNode(remNode)
+	}
+	for _, depNode := range xmlquery.Find(featureNode, "/deprecate") {
+		note := fmt.Sprintf("deprecated as of %s", rval.featureName)
+		if link := depNode.SelectAttr("explanationlink"); link != "" {
+			note = fmt.Sprintf("%s; see %s", note, link)
+		}
+		for _, n := range xmlquery.Find(depNode, "/type | /command") {
+			rval.deprecationNotes[n.SelectAttr("name")] = note
+		}
+	}
+
+	return rval
+}
+
+// readRemoveNode records the types, commands and enums listed in a <remove> block. Removals take effect when the
+// feature is merged into another, in MergeWith, so a later feature (or extension) can drop names required by an
+// earlier one.
+func (f *Feature) readRemoveNode(remNode *xmlquery.Node) {
+	for _, n := range xmlquery.Find(remNode, "/type | /command") {
+		if def.ApiListMatches(n.SelectAttr("api"), f.targetApi) {
+			f.removeTypeNames[n.SelectAttr("name")] = true
+		}
+	}
+	for _, n := range xmlquery.Find(remNode, "/enum") {
+		if def.ApiListMatches(n.SelectAttr("api"), f.targetApi) {
+			f.removeValueNames[n.SelectAttr("name")] = true
+		}
+	}
+}
+
+// applyRemovals drops every removed name from the feature's require lists
+func (f *Feature) applyRemovals() {
+	for k := range f.removeTypeNames {
+		delete(f.requireTypeNames, k)
+	}
+	for k := range f.removeValueNames {
+		delete(f.requireValueNames, k)
+	}
+}
+
+// MarkDeprecated sets a deprecation note on each type and command listed in the feature's <deprecate> blocks.
+func (f *Feature) MarkDeprecated(tr def.TypeRegistry) {
+	for k, note := range f.deprecationNotes {
+		if td, found := tr[k]; found {
+			td.SetDeprecationNote(note)
 		}
+	}
+}
 
-		for _, cmdNode := range xmlquery.Find(reqNode, "/command") {
-			rval.requireTypeNames[cmdNode.SelectAttr("name")] = true
+// readRequireNode adds the types, commands and enums listed in a <require> block to the feature. Enums that define
+// a new value (i.e., extend a type or provide a value) are also added to the value registry. extNumber must be
+// non-zero when reading an extension, and is used to calculate offset values.
+//
+// If the block has a depends attribute, the names are held separately until ResolveConditionalRequires is called
+// with the final generation set.
+func (f *Feature) readRequireNode(reqNode *xmlquery.Node, extNumber int, tr def.TypeRegistry, vr def.ValueRegistry) {
+	target := f
+	if dependsString := reqNode.SelectAttr("depends"); dependsString != "" {
+		if expr, err := ParseDepends(dependsString); err != nil {
+			logrus.WithField("feature", f.featureName).
+				WithField("depends", dependsString).
+				WithError(err).
+				Error("could not parse depends attribute on require block; block will be included unconditionally")
+		} else {
+			target = NewFeature()
+			target.targetApi = f.targetApi
+			f.conditionalRequires = append(f.conditionalRequires, conditionalRequire{expr, target})
 		}
+	}
 
-		for _, enumNode := range xmlquery.Find(reqNode, "/enum") {
-			extendsTypeName := enumNode.SelectAttr("extends")
+	apiFilter := fmt.Sprintf("[%s]", def.ApiPredicate("api", f.targetApi))
+
+	for _, typeNode := range xmlquery.Find(reqNode, "/type"+apiFilter) {
+		target.requireTypeNames[typeNode.SelectAttr("name")] = true
+	}
 
-			if extendsTypeName != "" {
-				// Defines a new enum value, which extends a global type
-				td := tr[extendsTypeName]
-				if enumNode.SelectAttr("bitpos") != "" {
-					vd := def.NewBitmaskValueFromXML(td, enumNode)
-					vr[vd.RegistryName()] = vd
-				} else {
-					vd := def.NewEnumValueFromXML(td, enumNode)
-					vr[vd.RegistryName()] = vd
+	for _, cmdNode := range xmlquery.Find(reqNode, "/command"+apiFilter) {
+		target.requireTypeNames[cmdNode.SelectAttr("name")] = true
+	}
+
+	for _, enumNode := range xmlquery.Find(reqNode, "/enum"+apiFilter) {
+		extendsTypeName := enumNode.SelectAttr("extends")
+
+		if extendsTypeName == "" && enumNode.SelectAttr("value") == "" && enumNode.SelectAttr("alias") == "" {
+			// Requiring an outside constant, like VK_SHADER_UNUSED_KHR or VK_ATTACHMENT_UNUSED; These should
+			// already be in the registry as external types
+			target.requireValueNames[enumNode.SelectAttr("name")] = true
+			continue
+		}
+
+		var vd def.ValueDefiner
+
+		if td, found := tr[extendsTypeName]; found {
+			// Defines a new enum value, which extends a global type
+			if enumNode.SelectAttr("bitpos") != "" {
+				vd = def.NewBitmaskValueFromXML(td, enumNode)
+				if !def.BitposFits(td, vd) {
+					delete(vr, vd.RegistryName())
+					continue
 				}
+			} else {
+				vd = def.NewEnumValueFromXML(td, enumNode)
 			}
+		} else {
+			vd = def.NewUntypedEnumValueFromXML(enumNode)
+		}
+		if extNumber != 0 {
+			vd.SetExtensionNumber(extNumber)
+		}
+		vr[vd.RegistryName()] = vd
+
+		target.requireValueNames[enumNode.SelectAttr("name")] = true
+	}
+}
 
-			rval.requireValueNames[enumNode.SelectAttr("name")] = true
+// ResolveConditionalRequires merges every <require depends="..."> block whose dependency expression is satisfied
+// into the feature's require lists. Blocks that are not satisfied are discarded, so that types and values depending
+// on an extension or version outside of the generation set are not included.
+func (f *Feature) ResolveConditionalRequires(available func(name string) bool) {
+	for _, c := range f.conditionalRequires {
+		if c.depends.Eval(available) {
+			f.MergeWith(c.Feature)
 		}
 	}
+	f.conditionalRequires = nil
 
-	return rval
+	// A removal still applies to anything re-required by a conditional block
+	f.applyRemovals()
 }
 
 func (f *Feature) Name() string { return f.featureName }
@@ -165,4 +329,36 @@ func (f *Feature) MergeWith(g *Feature) {
 	for k, v := range g.requireValueNames {
 		f.requireValueNames[k] = v
 	}
+	f.conditionalRequires = append(f.conditionalRequires, g.conditionalRequires...)
+
+	for k := range g.removeTypeNames {
+		f.removeTypeNames[k] = true
+	}
+	for k := range g.removeValueNames {
+		f.removeValueNames[k] = true
+	}
+	f.applyRemovals()
+
+	for k, v := range g.deprecationNotes {
+		f.deprecationNotes[k] = v
+	}
+}
+
+func (f *Feature) ApiName() string { return f.apiName }
+func (f *Feature) Version() string { return f.version }
+
+// ParseVersion splits a "major.minor" feature number, as used in the number attribute of a <feature> element, into
+// its components.
+func ParseVersion(version string) (major, minor int, err error) {
+	parts := strings.Split(version, ".")
+	if len(parts) != 2 {
+		return 0, 0, fmt.Errorf("version %q is not of the form major.minor", version)
+	}
+	if major, err = strconv.Atoi(parts[0]); err != nil {
+		return 0, 0, fmt.Errorf("version %q has an invalid major number: %w", version, err)
+	}
+	if minor, err = strconv.Atoi(parts[1]); err != nil {
+		return 0, 0, fmt.Errorf("version %q has an invalid minor number: %w", version, err)
+	}
+	return major, minor, nil
 }
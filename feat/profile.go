@@ -0,0 +1,202 @@
+package feat
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/antchfx/xmlquery"
+	"github.com/bbredesen/vk-gen/def"
+)
+
+// ApiProfile describes how the core versions of an API are named in the registry. Vulkan SC, for example, is built
+// on top of Vulkan 1.2: its own VKSC_VERSION_1_0 feature depends on VK_VERSION_1_2 and removes anything not
+// supported in a safety critical environment.
+type ApiProfile struct {
+	Api string
+	// FeaturePrefix is the name prefix of the API's own <feature> elements
+	FeaturePrefix string
+	// Variant is the variant number encoded in VK_MAKE_API_VERSION
+	Variant int
+	// BaseFeature is the feature that the API's first version builds on, used when the registry does not provide a
+	// depends attribute on features. It is empty for an API with a standalone core.
+	BaseFeature string
+}
+
+var apiProfiles = map[string]ApiProfile{
+	"vulkan": {
+		Api:           "vulkan",
+		FeaturePrefix: "VK_VERSION_",
+		Variant:       0,
+	},
+	"vulkansc": {
+		Api:           "vulkansc",
+		FeaturePrefix: "VKSC_VERSION_",
+		Variant:       1,
+		BaseFeature:   "VK_VERSION_1_2",
+	},
+}
+
+// ProfileForApi returns the generation profile for the named API, or an error if vk-gen does not support it.
+func ProfileForApi(api string) (ApiProfile, error) {
+	if p, found := apiProfiles[api]; found {
+		return p, nil
+	}
+	names := make([]string, 0, len(apiProfiles))
+	for k := range apiProfiles {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	return ApiProfile{}, fmt.Errorf("unsupported api %q; possible values are %s", api, strings.Join(names, ", "))
+}
+
+// FindFeatureNodesForApi returns the chain of <feature> elements making up the core of the named API, ordered so
+// that each feature follows the features it depends on. The chain ends with the API's own feature numbered
+// maxVersion, or with its latest feature if maxVersion is empty.
+//
+// Dependencies are taken from the depends attribute of each feature. For registries without that attribute, a
+// feature depends on the previous version in its series, and the API's first version depends on the profile's
+// BaseFeature.
+func FindFeatureNodesForApi(doc *xmlquery.Node, api, maxVersion string) ([]*xmlquery.Node, error) {
+	profile, err := ProfileForApi(api)
+	if err != nil {
+		return nil, err
+	}
+
+	type versionedNode struct {
+		major, minor int
+		node         *xmlquery.Node
+	}
+	own := make([]versionedNode, 0)
+	byName := make(map[string]*xmlquery.Node)
+
+	for _, node := range xmlquery.Find(doc, "//feature") {
+		if !def.ApiListContains(node.SelectAttr("api"), api) {
+			continue
+		}
+		name := node.SelectAttr("name")
+		byName[name] = node
+
+		if !strings.HasPrefix(name, profile.FeaturePrefix) {
+			continue
+		}
+		major, minor, err := ParseVersion(node.SelectAttr("number"))
+		if err != nil {
+			return nil, fmt.Errorf("feature %s: %w", name, err)
+		}
+		own = append(own, versionedNode{major, minor, node})
+	}
+
+	if len(own) == 0 {
+		return nil, nil
+	}
+
+	sort.SliceStable(own, func(i, j int) bool {
+		if own[i].major != own[j].major {
+			return own[i].major < own[j].major
+		}
+		return own[i].minor < own[j].minor
+	})
+
+	targetIndex := len(own) - 1
+	if maxVersion != "" {
+		maxMajor, maxMinor, err := ParseVersion(maxVersion)
+		if err != nil {
+			return nil, err
+		}
+		targetIndex = -1
+		for i, v := range own {
+			if v.major == maxMajor && v.minor == maxMinor {
+				targetIndex = i
+			}
+		}
+		if targetIndex < 0 {
+			return nil, fmt.Errorf("no feature with number %s found for api %s", maxVersion, api)
+		}
+	}
+
+	// The previous version of each feature, for the fallback when depends is missing. This covers the API's own
+	// features and any it builds on, e.g. VK_VERSION_1_1 before VK_VERSION_1_2 for vulkansc.
+	previous, err := previousVersions(byName)
+	if err != nil {
+		return nil, err
+	}
+	isOwn := make(map[string]bool)
+	for _, v := range own {
+		isOwn[v.node.SelectAttr("name")] = true
+	}
+
+	rval := make([]*xmlquery.Node, 0)
+	visited := make(map[string]bool)
+
+	var visit func(node *xmlquery.Node) error
+	visit = func(node *xmlquery.Node) error {
+		name := node.SelectAttr("name")
+		if visited[name] {
+			return nil
+		}
+		visited[name] = true
+
+		var deps []string
+		if dependsString := node.SelectAttr("depends"); dependsString != "" {
+			expr, err := ParseDepends(dependsString)
+			if err != nil {
+				return fmt.Errorf("feature %s: %w", name, err)
+			}
+			deps = expr.Names()
+		} else if prev, found := previous[name]; found {
+			deps = []string{prev}
+		} else if isOwn[name] && profile.BaseFeature != "" {
+			deps = []string{profile.BaseFeature}
+		}
+
+		for _, dep := range deps {
+			if depNode, found := byName[dep]; found {
+				if err := visit(depNode); err != nil {
+					return err
+				}
+			}
+		}
+		rval = append(rval, node)
+		return nil
+	}
+
+	if err := visit(own[targetIndex].node); err != nil {
+		return nil, err
+	}
+	return rval, nil
+}
+
+// previousVersions maps the name of each feature to the feature with the next lower number in the same series, where
+// a series is the feature name without its version suffix (e.g. VK_VERSION_ or VKSC_VERSION_). The first feature of
+// each series is left out.
+func previousVersions(features map[string]*xmlquery.Node) (map[string]string, error) {
+	type versionedName struct {
+		name         string
+		major, minor int
+	}
+	series := make(map[string][]versionedName)
+
+	for name, node := range features {
+		major, minor, err := ParseVersion(node.SelectAttr("number"))
+		if err != nil {
+			return nil, fmt.Errorf("feature %s: %w", name, err)
+		}
+		prefix := strings.TrimSuffix(name, fmt.Sprintf("%d_%d", major, minor))
+		series[prefix] = append(series[prefix], versionedName{name, major, minor})
+	}
+
+	rval := make(map[string]string)
+	for _, s := range series {
+		sort.Slice(s, func(i, j int) bool {
+			if s[i].major != s[j].major {
+				return s[i].major < s[j].major
+			}
+			return s[i].minor < s[j].minor
+		})
+		for i := 1; i < len(s); i++ {
+			rval[s[i].name] = s[i-1].name
+		}
+	}
+	return rval, nil
+}
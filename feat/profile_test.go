@@ -0,0 +1,115 @@
+package feat
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/antchfx/xmlquery"
+)
+
+// Features with depends attributes, as in current registries
+const dependsRegistry = `<registry>
+	<feature api="vulkan,vulkansc" name="VK_VERSION_1_0" number="1.0"/>
+	<feature api="vulkan,vulkansc" name="VK_VERSION_1_1" number="1.1" depends="VK_VERSION_1_0"/>
+	<feature api="vulkan,vulkansc" name="VK_VERSION_1_2" number="1.2" depends="VK_VERSION_1_1"/>
+	<feature api="vulkan" name="VK_VERSION_1_3" number="1.3" depends="VK_VERSION_1_2"/>
+	<feature api="vulkansc" name="VKSC_VERSION_1_0" number="1.0" depends="VK_VERSION_1_2"/>
+</registry>`
+
+// Features without depends attributes, out of order, as in older registries
+const noDependsRegistry = `<registry>
+	<feature api="vulkansc" name="VKSC_VERSION_1_0" number="1.0"/>
+	<feature api="vulkan,vulkansc" name="VK_VERSION_1_1" number="1.1"/>
+	<feature api="vulkan" name="VK_VERSION_1_3" number="1.3"/>
+	<feature api="vulkan,vulkansc" name="VK_VERSION_1_0" number="1.0"/>
+	<feature api="vulkan,vulkansc" name="VK_VERSION_1_2" number="1.2"/>
+</registry>`
+
+func TestProfileForApi(t *testing.T) {
+	cases := []struct {
+		api  string
+		want ApiProfile
+	}{
+		{"vulkan", ApiProfile{Api: "vulkan", FeaturePrefix: "VK_VERSION_", Variant: 0}},
+		{"vulkansc", ApiProfile{Api: "vulkansc", FeaturePrefix: "VKSC_VERSION_", Variant: 1, BaseFeature: "VK_VERSION_1_2"}},
+	}
+	for _, c := range cases {
+		got, err := ProfileForApi(c.api)
+		if err != nil || got != c.want {
+			t.Errorf("ProfileForApi(%q) = %+v, %v; want %+v", c.api, got, err, c.want)
+		}
+	}
+
+	if _, err := ProfileForApi("opengl"); err == nil || !strings.Contains(err.Error(), "vulkan, vulkansc") {
+		t.Errorf("ProfileForApi(\"opengl\") returned error %v, want one listing the supported apis", err)
+	}
+}
+
+func TestFindFeatureNodesForApi(t *testing.T) {
+	cases := []struct {
+		registry, api, maxVersion string
+		want                      []string
+	}{
+		{dependsRegistry, "vulkan", "", []string{"VK_VERSION_1_0", "VK_VERSION_1_1", "VK_VERSION_1_2", "VK_VERSION_1_3"}},
+		{dependsRegistry, "vulkan", "1.1", []string{"VK_VERSION_1_0", "VK_VERSION_1_1"}},
+		{dependsRegistry, "vulkansc", "", []string{"VK_VERSION_1_0", "VK_VERSION_1_1", "VK_VERSION_1_2", "VKSC_VERSION_1_0"}},
+		{dependsRegistry, "vulkansc", "1.0", []string{"VK_VERSION_1_0", "VK_VERSION_1_1", "VK_VERSION_1_2", "VKSC_VERSION_1_0"}},
+		// Without depends, each feature follows its previous version and vulkansc builds on VK_VERSION_1_2
+		{noDependsRegistry, "vulkan", "", []string{"VK_VERSION_1_0", "VK_VERSION_1_1", "VK_VERSION_1_2", "VK_VERSION_1_3"}},
+		{noDependsRegistry, "vulkan", "1.2", []string{"VK_VERSION_1_0", "VK_VERSION_1_1", "VK_VERSION_1_2"}},
+		{noDependsRegistry, "vulkansc", "", []string{"VK_VERSION_1_0", "VK_VERSION_1_1", "VK_VERSION_1_2", "VKSC_VERSION_1_0"}},
+	}
+
+	for _, c := range cases {
+		doc, err := xmlquery.Parse(strings.NewReader(c.registry))
+		if err != nil {
+			t.Fatal(err)
+		}
+		nodes, err := FindFeatureNodesForApi(doc, c.api, c.maxVersion)
+		if err != nil {
+			t.Errorf("%s %q: %v", c.api, c.maxVersion, err)
+			continue
+		}
+
+		got := make([]string, len(nodes))
+		for i, n := range nodes {
+			got[i] = n.SelectAttr("name")
+		}
+		if !reflect.DeepEqual(got, c.want) {
+			t.Errorf("%s %q: got %v, want %v", c.api, c.maxVersion, got, c.want)
+		}
+	}
+}
+
+func TestFindFeatureNodesForApiErrors(t *testing.T) {
+	badNumber := `<registry><feature api="vulkan" name="VK_VERSION_1_0" number="1.a"/></registry>`
+	badDepends := `<registry>
+		<feature api="vulkan" name="VK_VERSION_1_0" number="1.0"/>
+		<feature api="vulkan" name="VK_VERSION_1_1" number="1.1" depends="VK_VERSION_1_0+"/>
+	</registry>`
+
+	cases := []struct {
+		registry, api, maxVersion, want string
+	}{
+		{dependsRegistry, "vulkan", "1.5", "no feature with number 1.5 found for api vulkan"},
+		{dependsRegistry, "vulkansc", "1.2", "no feature with number 1.2 found for api vulkansc"},
+		{dependsRegistry, "vulkan", "1", "not of the form major.minor"},
+		{dependsRegistry, "vulkan", "1.2.3", "not of the form major.minor"},
+		{dependsRegistry, "vulkan", "v1.2", "invalid major number"},
+		{dependsRegistry, "vulkan", "1.x", "invalid minor number"},
+		{dependsRegistry, "opengl", "", "unsupported api"},
+		{badNumber, "vulkan", "", "feature VK_VERSION_1_0"},
+		{badDepends, "vulkan", "", "feature VK_VERSION_1_1"},
+	}
+
+	for _, c := range cases {
+		doc, err := xmlquery.Parse(strings.NewReader(c.registry))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := FindFeatureNodesForApi(doc, c.api, c.maxVersion); err == nil || !strings.Contains(err.Error(), c.want) {
+			t.Errorf("%s %q: got error %v, want one containing %q", c.api, c.maxVersion, err, c.want)
+		}
+	}
+}
@@ -0,0 +1,87 @@
+package gen
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// generateFixture generates testdata/vk.xml in memory for each api, with the exceptions and static files from the
+// repository root
+func generateFixture(t *testing.T, apis ...string) (Result, *MemFS) {
+	t.Helper()
+
+	opts := Options{
+		ExceptionsFiles: []string{"../exceptions.json"},
+		StaticDir:       "../static_include",
+		Output:          NewMemFS(),
+	}
+	for _, api := range apis {
+		opts.Targets = append(opts.Targets, Target{RegistryFile: "testdata/vk.xml", OutDir: api, Api: api})
+	}
+
+	res, err := Generate(context.Background(), opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return res, opts.Output.(*MemFS)
+}
+
+// TestApiSelection checks that each API gets exactly the types, members, params and extensions tagged for it in the
+// fixture registry, including lists like "vulkan,vulkansc" and "vulkan, vulkansc"
+func TestApiSelection(t *testing.T) {
+	res, mem := generateFixture(t, "vulkan", "vulkansc")
+
+	wantExtensions := map[string][]string{
+		"vulkan":   {"VK_AMDX_thing", "VK_KHR_surface", "VK_KHR_synchronization2", "VK_NV_thing"},
+		"vulkansc": {"VK_KHR_surface"},
+	}
+	for _, tr := range res.Targets {
+		if !reflect.DeepEqual(tr.Extensions, wantExtensions[tr.OutDir]) {
+			t.Errorf("%s: extensions are %v, want %v", tr.OutDir, tr.Extensions, wantExtensions[tr.OutDir])
+		}
+	}
+
+	cases := []struct {
+		file, text       string
+		vulkan, vulkansc bool
+	}{
+		// Commands with api="vulkan", "vulkansc" and "vulkan, vulkansc"
+		{"command.go", "func CmdPipelineBarrier2(", true, false},
+		{"command.go", "func GetFaultData(", false, true},
+		{"command.go", "func CmdSharedThing(", true, true},
+		// A param with api="vulkansc"
+		{"command.go", "func CmdSharedThing(device Device) {", true, false},
+		{"command.go", "func CmdSharedThing(device Device, scParam uint32) {", false, true},
+		// Members with api="vulkan" and api="vulkansc"
+		{"struct.go", "\tDesktopOnly ", true, false},
+		{"struct.go", "\tScReserved ", false, true},
+		// Types only required by one API
+		{"struct.go", "type DependencyInfo struct", true, false},
+		{"struct.go", "type FaultData struct", false, true},
+		// A core enum value removed by VKSC_VERSION_1_0
+		{"enum.go", "FORMAT_UNDEFINED ", true, true},
+		{"enum.go", "FORMAT_R8G8B8A8_UNORM ", true, false},
+		// Defines with api="vulkan" and api="vulkansc"
+		{"define.go", "var API_VERSION_1_0 ", true, false},
+		{"define.go", "var VKSC_API_VERSION_1_0 ", false, true},
+		// Extension constants; VK_KHR_disabled_thing is supported="disabled"
+		{"exten.go", "NV_THING_EXTENSION_NAME", true, false},
+		{"exten.go", "KHR_SURFACE_EXTENSION_NAME", true, true},
+		{"exten.go", "DISABLED_THING", false, false},
+	}
+
+	for _, c := range cases {
+		for api, want := range map[string]bool{"vulkan": c.vulkan, "vulkansc": c.vulkansc} {
+			data, found := mem.ReadFile(api + "/" + c.file)
+			if !found {
+				t.Errorf("%s/%s was not generated", api, c.file)
+				continue
+			}
+			if got := strings.Contains(string(data), c.text); got != want {
+				t.Errorf("%s/%s contains %q: %v, want %v", api, c.file, c.text, got, want)
+			}
+		}
+	}
+}
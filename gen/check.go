@@ -0,0 +1,77 @@
+package gen
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+)
+
+// FileDifference describes a generated file that does not match the existing file on disk.
+type FileDifference struct {
+	Path string
+	// Missing is set if the file does not exist on disk
+	Missing bool
+	// Stale is set if the file is listed in the manifest on disk but is no longer generated, so that running vk-gen
+	// would remove it
+	Stale bool
+	// Diff is a unified diff from the file on disk to the generated file
+	Diff string
+}
+
+// CompareOutput compares every file in mem, as written by Generate, with the file of the same name on disk. Relative
+// names are resolved against root, or against the working directory if root is empty, the same as DiskFS. Nothing
+// on disk is modified. Differences are returned in file name order, followed by any stale files.
+func CompareOutput(mem *MemFS, root string) ([]FileDifference, error) {
+	rval := make([]FileDifference, 0)
+	disk := DiskFS{Root: root}
+
+	for _, name := range mem.Names() {
+		generated, _ := mem.ReadFile(name)
+
+		p := disk.path(name)
+		existing, err := os.ReadFile(p)
+		if errors.Is(err, fs.ErrNotExist) {
+			rval = append(rval, FileDifference{
+				Path:    name,
+				Missing: true,
+				Diff:    unifiedDiff("/dev/null", "b/"+name, nil, generated),
+			})
+			continue
+		} else if err != nil {
+			return rval, fmt.Errorf("could not read %s: %w", p, err)
+		}
+
+		if diff := unifiedDiff("a/"+name, "b/"+name, existing, generated); diff != "" {
+			rval = append(rval, FileDifference{Path: name, Diff: diff})
+		}
+	}
+
+	for _, name := range mem.Names() {
+		if path.Base(name) != ManifestFile {
+			continue
+		}
+		previous, err := disk.ReadFile(name)
+		if err != nil {
+			continue // A missing manifest was reported above
+		}
+
+		generated, _ := mem.ReadFile(name)
+		for _, stale := range staleFiles(previous, parseManifest(generated)) {
+			stalePath := path.Join(path.Dir(name), stale)
+			existing, err := disk.ReadFile(stalePath)
+			if errors.Is(err, fs.ErrNotExist) {
+				continue
+			} else if err != nil {
+				return rval, fmt.Errorf("could not read %s: %w", stalePath, err)
+			}
+			rval = append(rval, FileDifference{
+				Path:  stalePath,
+				Stale: true,
+				Diff:  unifiedDiff("a/"+stalePath, "/dev/null", existing, nil),
+			})
+		}
+	}
+	return rval, nil
+}
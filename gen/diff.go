@@ -0,0 +1,186 @@
+package gen
+
+import (
+	"fmt"
+	"strings"
+)
+
+// diffContext is the number of unchanged lines shown around each change in a unified diff
+const diffContext = 3
+
+// maxDiffEdits bounds the work done by diffLines. If the files differ by more edits than this, the diff shows the
+// whole of one file replaced by the other.
+const maxDiffEdits = 2000
+
+type diffOp struct {
+	kind byte // ' ', '-' or '+'
+	a, b int  // Line index in each file at the start of the op
+}
+
+// unifiedDiff returns a unified diff from a to b, or "" if they are identical
+func unifiedDiff(aName, bName string, a, b []byte) string {
+	aLines, bLines := splitLines(a), splitLines(b)
+	ops := diffLines(aLines, bLines)
+
+	sb := &strings.Builder{}
+	for i := 0; i < len(ops); {
+		if ops[i].kind == ' ' {
+			i++
+			continue
+		}
+
+		// Extend the hunk over every change that is within 2*diffContext lines of the previous one
+		start := max(i-diffContext, 0)
+		end := i
+		for j := i; j < len(ops); j++ {
+			if ops[j].kind != ' ' {
+				end = j
+			} else if j-end > 2*diffContext {
+				break
+			}
+		}
+		end = min(end+diffContext+1, len(ops))
+
+		if sb.Len() == 0 {
+			fmt.Fprintf(sb, "--- %s\n+++ %s\n", aName, bName)
+		}
+
+		aCount, bCount := 0, 0
+		for _, op := range ops[start:end] {
+			if op.kind != '+' {
+				aCount++
+			}
+			if op.kind != '-' {
+				bCount++
+			}
+		}
+		fmt.Fprintf(sb, "@@ -%s +%s @@\n", hunkRange(ops[start].a, aCount), hunkRange(ops[start].b, bCount))
+
+		for _, op := range ops[start:end] {
+			line := ""
+			switch op.kind {
+			case ' ', '-':
+				line = aLines[op.a]
+			case '+':
+				line = bLines[op.b]
+			}
+			sb.WriteByte(op.kind)
+			sb.WriteString(line)
+			if !strings.HasSuffix(line, "\n") {
+				sb.WriteString("\n\\ No newline at end of file\n")
+			}
+		}
+
+		i = end
+	}
+	return sb.String()
+}
+
+func hunkRange(start, count int) string {
+	if count == 0 {
+		// An empty range names the line before it
+		return fmt.Sprintf("%d,0", start)
+	}
+	return fmt.Sprintf("%d,%d", start+1, count)
+}
+
+// splitLines splits data into lines, each keeping its trailing newline
+func splitLines(data []byte) []string {
+	lines := strings.SplitAfter(string(data), "\n")
+	if len(lines) > 0 && lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// diffLines returns the shortest edit script from a to b, using Myers' algorithm.
+func diffLines(a, b []string) []diffOp {
+	n, m := len(a), len(b)
+	offset := n + m + 1
+	v := make([]int, 2*(n+m)+3)
+
+	// trace[d] holds v[-d..d] after step d
+	trace := make([][]int, 0)
+
+	for d := 0; d <= n+m; d++ {
+		if d > maxDiffEdits {
+			return replaceAll(n, m)
+		}
+
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+				x = v[offset+k+1] // Insertion
+			} else {
+				x = v[offset+k-1] + 1 // Deletion
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			v[offset+k] = x
+
+			if x >= n && y >= m {
+				trace = append(trace, append([]int(nil), v[offset-d:offset+d+1]...))
+				return backtrack(trace, n, m)
+			}
+		}
+		trace = append(trace, append([]int(nil), v[offset-d:offset+d+1]...))
+	}
+	return nil // Unreachable, d = n+m always reaches the end
+}
+
+func backtrack(trace [][]int, n, m int) []diffOp {
+	rval := make([]diffOp, 0, n+m)
+	x, y := n, m
+
+	for d := len(trace) - 1; d > 0; d-- {
+		prev := trace[d-1]
+		get := func(k int) int { return prev[k+d-1] }
+
+		k := x - y
+		var prevK int
+		if k == -d || (k != d && get(k-1) < get(k+1)) {
+			prevK = k + 1
+		} else {
+			prevK = k - 1
+		}
+		prevX := get(prevK)
+		prevY := prevX - prevK
+
+		for x > prevX && y > prevY {
+			x--
+			y--
+			rval = append(rval, diffOp{' ', x, y})
+		}
+		if x == prevX {
+			y--
+			rval = append(rval, diffOp{'+', x, y})
+		} else {
+			x--
+			rval = append(rval, diffOp{'-', x, y})
+		}
+	}
+	for x > 0 && y > 0 {
+		x--
+		y--
+		rval = append(rval, diffOp{' ', x, y})
+	}
+
+	for i, j := 0, len(rval)-1; i < j; i, j = i+1, j-1 {
+		rval[i], rval[j] = rval[j], rval[i]
+	}
+	return rval
+}
+
+func replaceAll(n, m int) []diffOp {
+	rval := make([]diffOp, 0, n+m)
+	for i := 0; i < n; i++ {
+		rval = append(rval, diffOp{'-', i, 0})
+	}
+	for i := 0; i < m; i++ {
+		rval = append(rval, diffOp{'+', n, i})
+	}
+	return rval
+}
@@ -0,0 +1,220 @@
+package gen
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestHunkRange(t *testing.T) {
+	cases := []struct {
+		start, count int
+		want         string
+	}{
+		{0, 0, "0,0"},
+		{3, 0, "3,0"},
+		{0, 1, "1,1"},
+		{4, 2, "5,2"},
+	}
+	for _, c := range cases {
+		if got := hunkRange(c.start, c.count); got != c.want {
+			t.Errorf("hunkRange(%d, %d) = %q, want %q", c.start, c.count, got, c.want)
+		}
+	}
+}
+
+// numberedLines returns lines 1 to n, replacing the lines in replace
+func numberedLines(n int, replace map[int]string) string {
+	sb := &strings.Builder{}
+	for i := 1; i <= n; i++ {
+		if r, found := replace[i]; found {
+			sb.WriteString(r + "\n")
+		} else {
+			fmt.Fprintf(sb, "%d\n", i)
+		}
+	}
+	return sb.String()
+}
+
+func TestUnifiedDiff(t *testing.T) {
+	cases := []struct {
+		name, a, b, want string
+	}{
+		{"identical", "x\ny\n", "x\ny\n", ""},
+		{
+			"one change",
+			numberedLines(10, nil), numberedLines(10, map[int]string{5: "five"}),
+			"--- a\n+++ b\n@@ -2,7 +2,7 @@\n 2\n 3\n 4\n-5\n+five\n 6\n 7\n 8\n",
+		},
+		{
+			"changes within the context are one hunk",
+			numberedLines(12, nil), numberedLines(12, map[int]string{2: "two", 8: "eight"}),
+			"--- a\n+++ b\n@@ -1,11 +1,11 @@\n 1\n-2\n+two\n 3\n 4\n 5\n 6\n 7\n-8\n+eight\n 9\n 10\n 11\n",
+		},
+		{
+			"distant changes are separate hunks",
+			numberedLines(20, nil), numberedLines(20, map[int]string{2: "two", 18: "eighteen"}),
+			"--- a\n+++ b\n@@ -1,5 +1,5 @@\n 1\n-2\n+two\n 3\n 4\n 5\n" +
+				"@@ -15,6 +15,6 @@\n 15\n 16\n 17\n-18\n+eighteen\n 19\n 20\n",
+		},
+		{"new file", "", "x\ny\n", "--- a\n+++ b\n@@ -0,0 +1,2 @@\n+x\n+y\n"},
+		{"removed file", "x\ny\n", "", "--- a\n+++ b\n@@ -1,2 +0,0 @@\n-x\n-y\n"},
+		{"insertion", "x\nz\n", "x\ny\nz\n", "--- a\n+++ b\n@@ -1,2 +1,3 @@\n x\n+y\n z\n"},
+		{
+			"added line without a newline",
+			"x\n", "x\ny",
+			"--- a\n+++ b\n@@ -1,1 +1,2 @@\n x\n+y\n\\ No newline at end of file\n",
+		},
+		{
+			"newline added at end of file",
+			"x\ny", "x\ny\n",
+			"--- a\n+++ b\n@@ -1,2 +1,2 @@\n x\n-y\n\\ No newline at end of file\n+y\n",
+		},
+	}
+
+	for _, c := range cases {
+		if got := unifiedDiff("a", "b", []byte(c.a), []byte(c.b)); got != c.want {
+			t.Errorf("%s: got\n%s\nwant\n%s", c.name, got, c.want)
+		}
+	}
+}
+
+// applyOps rebuilds both files from an edit script, checking that each op refers to the right line
+func applyOps(t *testing.T, a, b []string, ops []diffOp) {
+	t.Helper()
+
+	x, y := 0, 0
+	for _, op := range ops {
+		if op.a != x || op.b != y {
+			t.Fatalf("op %q is at %d,%d, want %d,%d", op.kind, op.a, op.b, x, y)
+		}
+		switch op.kind {
+		case ' ':
+			if a[x] != b[y] {
+				t.Fatalf("unchanged op at %d,%d joins %q and %q", x, y, a[x], b[y])
+			}
+			x, y = x+1, y+1
+		case '-':
+			x++
+		case '+':
+			y++
+		}
+	}
+	if x != len(a) || y != len(b) {
+		t.Fatalf("edit script ends at %d,%d, want %d,%d", x, y, len(a), len(b))
+	}
+}
+
+func TestDiffLines(t *testing.T) {
+	a := splitLines([]byte("a\nb\nc\nd\ne\nf\n"))
+	b := splitLines([]byte("a\nc\nd\nx\ne\nf\ng\n"))
+
+	ops := diffLines(a, b)
+	applyOps(t, a, b, ops)
+
+	edits := 0
+	for _, op := range ops {
+		if op.kind != ' ' {
+			edits++
+		}
+	}
+	if edits != 3 {
+		t.Errorf("diffLines made %d edits, want 3", edits)
+	}
+}
+
+// TestDiffLinesFallback checks that files differing by more than maxDiffEdits are diffed as one file replacing the
+// other, even where they have lines in common
+func TestDiffLinesFallback(t *testing.T) {
+	a, b := make([]string, 0), make([]string, 0)
+	for i := 0; i < maxDiffEdits; i++ {
+		a = append(a, fmt.Sprintf("a%d\n", i))
+		b = append(b, fmt.Sprintf("b%d\n", i))
+	}
+	a = append(a, "common\n")
+	b = append(b, "common\n")
+
+	ops := diffLines(a, b)
+	applyOps(t, a, b, ops)
+	if len(ops) != len(a)+len(b) {
+		t.Errorf("diffLines returned %d ops, want %d", len(ops), len(a)+len(b))
+	}
+	for i, op := range ops {
+		if want := i < len(a); (op.kind == '-') != want {
+			t.Fatalf("op %d is %q, want the deletions of a followed by the insertions of b", i, op.kind)
+		}
+	}
+
+	// A single change is still found in files much larger than maxDiffEdits
+	a = append(a, b...)
+	b = append(append([]string{}, a...), "end\n")
+	if ops := diffLines(a, b); len(ops) != len(b) {
+		t.Errorf("diffLines returned %d ops for one insertion, want %d", len(ops), len(b))
+	}
+}
+
+func TestCompareOutput(t *testing.T) {
+	root := t.TempDir()
+	disk := DiskFS{Root: root}
+	for name, data := range map[string]string{
+		"vk/same.go":         "package vk\n",
+		"vk/changed.go":      "package vk\n\nvar x = 1\n",
+		"vk/old.go":          "package vk\n",
+		"vk/user.go":         "package vk\n",
+		"vk/" + ManifestFile: manifestHeader + "changed.go\ngone.go\nold.go\nsame.go\n",
+	} {
+		if err := disk.WriteFile(name, []byte(data)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	mem := NewMemFS()
+	for name, data := range map[string]string{
+		"vk/same.go":         "package vk\n",
+		"vk/changed.go":      "package vk\n\nvar x = 2\n",
+		"vk/new.go":          "package vk\n",
+		"vk/" + ManifestFile: manifestHeader + "changed.go\nnew.go\nsame.go\n",
+	} {
+		mem.WriteFile(name, []byte(data))
+	}
+
+	diffs, err := CompareOutput(mem, root)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// gone.go is listed in the old manifest but is not on disk, and user.go is not listed, so neither is stale
+	want := []FileDifference{
+		{Path: "vk/changed.go"},
+		{Path: "vk/new.go", Missing: true},
+		{Path: "vk/" + ManifestFile},
+		{Path: "vk/old.go", Stale: true},
+	}
+	if len(diffs) != len(want) {
+		t.Fatalf("CompareOutput returned %d differences, want %d: %+v", len(diffs), len(want), diffs)
+	}
+	for i, w := range want {
+		d := diffs[i]
+		if d.Path != w.Path || d.Missing != w.Missing || d.Stale != w.Stale {
+			t.Errorf("difference %d is %s (missing %v, stale %v), want %s (missing %v, stale %v)",
+				i, d.Path, d.Missing, d.Stale, w.Path, w.Missing, w.Stale)
+		}
+	}
+
+	if want := "--- a/vk/changed.go\n+++ b/vk/changed.go\n@@ -1,3 +1,3 @@\n package vk\n \n-var x = 1\n+var x = 2\n"; diffs[0].Diff != want {
+		t.Errorf("diff of changed.go is\n%s\nwant\n%s", diffs[0].Diff, want)
+	}
+	if !strings.HasPrefix(diffs[1].Diff, "--- /dev/null\n+++ b/vk/new.go\n@@ -0,0 +1,1 @@\n") {
+		t.Errorf("diff of missing file is\n%s", diffs[1].Diff)
+	}
+	if !strings.HasPrefix(diffs[3].Diff, "--- a/vk/old.go\n+++ /dev/null\n@@ -1,1 +0,0 @@\n") {
+		t.Errorf("diff of stale file is\n%s", diffs[3].Diff)
+	}
+
+	// Nothing on disk is changed
+	if _, err := os.Stat(filepath.Join(root, "vk", "old.go")); err != nil {
+		t.Errorf("CompareOutput changed the disk: %v", err)
+	}
+}
@@ -0,0 +1,82 @@
+package gen
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+)
+
+// MergeExceptions combines several exceptions documents into one. Layers are applied in order, so each layer
+// overrides the ones before it. The same rules apply to every section (define, handle, external, include, basetype,
+// struct, union, command and platform):
+//
+//   - An entry whose key is not already in the section is added.
+//   - An entry with the same key as an earlier one is merged into it field by field. Nested objects are merged the
+//     same way, while arrays, strings, numbers and booleans replace the earlier value.
+//   - A null value removes the earlier entry (or field), restoring vk-gen's default handling of that name.
+//   - A string entry, such as "!ignore" in the define section, replaces the earlier entry entirely.
+//
+// An overlay therefore only needs to hold the entries and fields it changes.
+func MergeExceptions(layers ...[]byte) ([]byte, error) {
+	var merged map[string]interface{}
+
+	for i, layer := range layers {
+		dec := json.NewDecoder(bytes.NewReader(layer))
+		dec.UseNumber()
+
+		var doc map[string]interface{}
+		if err := dec.Decode(&doc); err != nil {
+			return nil, fmt.Errorf("exceptions layer %d: %w", i+1, err)
+		}
+
+		if merged == nil {
+			merged = make(map[string]interface{})
+		}
+		mergeObject(merged, doc)
+	}
+
+	if merged == nil {
+		return nil, fmt.Errorf("no exceptions to merge")
+	}
+	return json.Marshal(merged)
+}
+
+// checkExceptionsSyntax returns an error with the byte offset of the first syntax error in an exceptions document, or
+// nil if it is valid JSON
+func checkExceptionsSyntax(data []byte) error {
+	if json.Valid(data) {
+		return nil
+	}
+
+	var v interface{}
+	err := json.Unmarshal(data, &v)
+	if serr, ok := err.(*json.SyntaxError); ok {
+		return fmt.Errorf("invalid JSON at offset %d: %w", serr.Offset, serr)
+	}
+	return fmt.Errorf("invalid JSON: %w", err)
+}
+
+// mergeObject applies src on top of dest, following the rules described on MergeExceptions
+func mergeObject(dest, src map[string]interface{}) {
+	for k, v := range src {
+		if v == nil {
+			delete(dest, k)
+			continue
+		}
+
+		srcObj, srcIsObj := v.(map[string]interface{})
+		destObj, destIsObj := dest[k].(map[string]interface{})
+		if srcIsObj && destIsObj {
+			mergeObject(destObj, srcObj)
+			continue
+		}
+
+		if srcIsObj {
+			// Copy, so that null values in the new object are dropped rather than kept
+			obj := make(map[string]interface{})
+			mergeObject(obj, srcObj)
+			v = obj
+		}
+		dest[k] = v
+	}
+}
@@ -0,0 +1,308 @@
+package gen
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/antchfx/xmlquery"
+	"github.com/tidwall/gjson"
+)
+
+// fieldKind is the JSON type expected for a field of an exceptions entry
+type fieldKind int
+
+const (
+	kindString fieldKind = iota
+	kindBool
+	kindStringOrNumber
+	kindStringArray
+	kindStringMap // An object whose values are all strings
+	kindEntryMap  // An object of named sub-entries, each checked against nestedSchema
+)
+
+func (k fieldKind) String() string {
+	switch k {
+	case kindString:
+		return "a string"
+	case kindBool:
+		return "a boolean"
+	case kindStringOrNumber:
+		return "a string or number"
+	case kindStringArray:
+		return "an array of strings"
+	case kindStringMap:
+		return "an object of strings"
+	case kindEntryMap:
+		return "an object"
+	}
+	return "unknown"
+}
+
+func (k fieldKind) matches(v gjson.Result) bool {
+	switch k {
+	case kindString:
+		return v.Type == gjson.String
+	case kindBool:
+		return v.IsBool()
+	case kindStringOrNumber:
+		return v.Type == gjson.String || v.Type == gjson.Number
+	case kindStringArray:
+		if !v.IsArray() {
+			return false
+		}
+		for _, e := range v.Array() {
+			if e.Type != gjson.String {
+				return false
+			}
+		}
+		return true
+	case kindStringMap:
+		if !v.IsObject() {
+			return false
+		}
+		ok := true
+		v.ForEach(func(_, e gjson.Result) bool {
+			ok = e.Type == gjson.String
+			return ok
+		})
+		return ok
+	case kindEntryMap:
+		return v.IsObject()
+	}
+	return false
+}
+
+// exceptionsSchema lists the fields read from each entry of each section of exceptions.json. Keys named "!comment"
+// are allowed anywhere and ignored.
+var exceptionsSchema = map[string]map[string]fieldKind{
+	"define": {
+		"!ignore":        kindBool,
+		"publicName":     kindString,
+		"underlyingType": kindString,
+		"functionName":   kindString,
+		"constantValue":  kindString,
+		"comment":        kindString,
+	},
+	"handle": {
+		"publicName":     kindString,
+		"underlyingType": kindString,
+		"comment":        kindString,
+		"constants":      kindStringMap,
+	},
+	"platform": {
+		"comment":    kindString,
+		"go:build":   kindString,
+		"go:imports": kindStringArray,
+	},
+	"external": {
+		"go:type":              kindString,
+		"go:translatePublic":   kindString,
+		"go:translateInternal": kindString,
+		"enums":                kindStringMap,
+	},
+	"include": {
+		"go:imports": kindStringArray,
+	},
+	"basetype": {
+		"underlyingTypeName":   kindString,
+		"go:type":              kindString,
+		"go:translatePublic":   kindString,
+		"go:translateInternal": kindString,
+		"comment":              kindString,
+	},
+	"struct": {
+		"publicName":          kindString,
+		"forceIncludeMember":  kindString,
+		"forceIncludeComment": kindString,
+		"members":             kindEntryMap,
+	},
+	"union": {
+		"go:internalSize": kindStringOrNumber,
+	},
+	"command": {
+		"publicName":    kindString,
+		"staticCodeRef": kindString,
+		"params":        kindEntryMap,
+	},
+}
+
+// nestedSchema lists the fields of each sub-entry of a kindEntryMap field, keyed by "section/field"
+var nestedSchema = map[string]map[string]fieldKind{
+	"struct/members": {
+		"publicName": kindString,
+		"typeName":   kindString,
+		"hide":       kindBool,
+		"expose":     kindBool,
+		"len":        kindString,
+		"comment":    kindString,
+	},
+	"command/params": {
+		"role":       kindString,
+		"publicName": kindString,
+		"optional":   kindBool,
+		"len":        kindString,
+	},
+}
+
+// stringEntries lists the sections where an entry may be a string instead of an object, and the allowed values
+var stringEntries = map[string][]string{
+	"define": {"!ignore"},
+}
+
+// ExceptionProblem is a schema violation found in exceptions.json.
+type ExceptionProblem struct {
+	// Path is a JSON pointer (RFC 6901) to the offending key or value, e.g. /handle/VK_DEFINE_HANDLE/publicNam
+	Path    string
+	Message string
+}
+
+func (p ExceptionProblem) String() string { return p.Path + ": " + p.Message }
+
+// jsonPointer joins keys into an RFC 6901 JSON pointer
+func jsonPointer(keys ...string) string {
+	sb := &strings.Builder{}
+	for _, k := range keys {
+		sb.WriteString("/")
+		sb.WriteString(strings.ReplaceAll(strings.ReplaceAll(k, "~", "~0"), "/", "~1"))
+	}
+	return sb.String()
+}
+
+// ValidateExceptions checks an exceptions document against the schema for each section, reporting unknown sections
+// and fields, and fields with the wrong type. Problems are sorted by path.
+func ValidateExceptions(doc gjson.Result) []ExceptionProblem {
+	rval := make([]ExceptionProblem, 0)
+	add := func(msg string, keys ...string) {
+		rval = append(rval, ExceptionProblem{jsonPointer(keys...), msg})
+	}
+
+	if !doc.IsObject() {
+		add("exceptions must be a JSON object")
+		return rval
+	}
+
+	doc.ForEach(func(sectionKey, section gjson.Result) bool {
+		sectionName := sectionKey.String()
+		if sectionName == "!comment" {
+			return true
+		}
+
+		schema, found := exceptionsSchema[sectionName]
+		if !found {
+			add("unknown section", sectionName)
+			return true
+		}
+		if !section.IsObject() {
+			add("section must be an object", sectionName)
+			return true
+		}
+
+		section.ForEach(func(entryKey, entry gjson.Result) bool {
+			name := entryKey.String()
+			if name == "!comment" {
+				return true
+			}
+
+			if entry.Type == gjson.String {
+				for _, allowed := range stringEntries[sectionName] {
+					if entry.String() == allowed {
+						return true
+					}
+				}
+				add(fmt.Sprintf("unexpected string %q; entry must be an object", entry.String()), sectionName, name)
+				return true
+			}
+			if !entry.IsObject() {
+				add("entry must be an object", sectionName, name)
+				return true
+			}
+
+			checkFields(entry, schema, add, sectionName, name)
+			entry.ForEach(func(fieldKey, value gjson.Result) bool {
+				field := fieldKey.String()
+				if schema[field] != kindEntryMap || !value.IsObject() {
+					return true
+				}
+				value.ForEach(func(subKey, sub gjson.Result) bool {
+					if subKey.String() == "!comment" {
+						return true
+					}
+					if !sub.IsObject() {
+						add("entry must be an object", sectionName, name, field, subKey.String())
+						return true
+					}
+					checkFields(sub, nestedSchema[sectionName+"/"+field], add, sectionName, name, field, subKey.String())
+					return true
+				})
+				return true
+			})
+			return true
+		})
+		return true
+	})
+
+	sort.SliceStable(rval, func(i, j int) bool { return rval[i].Path < rval[j].Path })
+	return rval
+}
+
+// checkFields reports unknown fields and fields of the wrong type in obj, which is found at path
+func checkFields(obj gjson.Result, schema map[string]fieldKind, add func(msg string, keys ...string), path ...string) {
+	obj.ForEach(func(fieldKey, value gjson.Result) bool {
+		field := fieldKey.String()
+		if field == "!comment" {
+			return true
+		}
+		keys := append(append([]string{}, path...), field)
+		kind, found := schema[field]
+		if !found {
+			add("unknown field", keys...)
+		} else if !kind.matches(value) {
+			add(fmt.Sprintf("must be %s", kind), keys...)
+		}
+		return true
+	})
+}
+
+// exceptionEntries returns the name of every entry in the document, keyed by section.
+func exceptionEntries(doc gjson.Result) map[string]map[string]bool {
+	rval := make(map[string]map[string]bool)
+	for section := range exceptionsSchema {
+		rval[section] = make(map[string]bool)
+		doc.Get(section).ForEach(func(key, _ gjson.Result) bool {
+			if key.String() != "!comment" {
+				rval[section][key.String()] = true
+			}
+			return true
+		})
+	}
+	return rval
+}
+
+// unusedExceptions returns a JSON pointer to each entry that did not match a name in used, sorted. Platform entries
+// are matched against usedPlatforms, and all other sections against usedNames.
+func unusedExceptions(doc gjson.Result, usedNames, usedPlatforms map[string]bool) []string {
+	rval := make([]string, 0)
+	for section, entries := range exceptionEntries(doc) {
+		used := usedNames
+		if section == "platform" {
+			used = usedPlatforms
+		}
+		for name := range entries {
+			if !used[name] {
+				rval = append(rval, jsonPointer(section, name))
+			}
+		}
+	}
+	sort.Strings(rval)
+	return rval
+}
+
+// registryNames returns the name of every type, command and enum in the registry, regardless of the API it belongs to
+func registryNames(xmlDoc *xmlquery.Node) map[string]bool {
+	rval := make(map[string]bool)
+	for _, n := range xmlquery.Find(xmlDoc, "//types/type/@name | //types/type/name | //commands/command/@name | //commands/command/proto/name | //enums/@name | //enums/enum/@name") {
+		rval[n.InnerText()] = true
+	}
+	return rval
+}
@@ -0,0 +1,153 @@
+package gen
+
+import (
+	"context"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/tidwall/gjson"
+)
+
+func TestJsonPointer(t *testing.T) {
+	cases := []struct {
+		keys []string
+		want string
+	}{
+		{nil, ""},
+		{[]string{"handle"}, "/handle"},
+		{[]string{"handle", "VkDevice", "publicName"}, "/handle/VkDevice/publicName"},
+		{[]string{"a/b", "c~d"}, "/a~1b/c~0d"},
+		{[]string{"~1"}, "/~01"},
+		{[]string{""}, "/"},
+	}
+	for _, c := range cases {
+		if got := jsonPointer(c.keys...); got != c.want {
+			t.Errorf("jsonPointer(%q) = %q, want %q", c.keys, got, c.want)
+		}
+	}
+}
+
+func TestValidateExceptions(t *testing.T) {
+	doc := `{
+		"!comment": "ignored",
+		"handel": {},
+		"handle": {
+			"VkDevice": {"publicNam": "Dev", "comment": 5, "!comment": "ignored"},
+			"a/b~c": {"publicName": 1}
+		},
+		"define": {"VK_A": "!ignore", "VK_B": "!skip", "VK_C": 3},
+		"struct": {
+			"VkS": {"members": {
+				"m/x": {"hide": "yes", "bogus": 1},
+				"ok": {"publicName": "P", "expose": true},
+				"bad": "string",
+				"!comment": "ignored"
+			}},
+			"VkT": {"members": []}
+		},
+		"command": {"vkC": {"params": {"p": {"optional": "true", "role": "inputSlice"}}}},
+		"platform": {"win32": {"go:imports": ["a", 1]}},
+		"union": []
+	}`
+
+	want := []ExceptionProblem{
+		{"/command/vkC/params/p/optional", "must be a boolean"},
+		{"/define/VK_B", `unexpected string "!skip"; entry must be an object`},
+		{"/define/VK_C", "entry must be an object"},
+		{"/handel", "unknown section"},
+		{"/handle/VkDevice/comment", "must be a string"},
+		{"/handle/VkDevice/publicNam", "unknown field"},
+		{"/handle/a~1b~0c/publicName", "must be a string"},
+		{"/platform/win32/go:imports", "must be an array of strings"},
+		{"/struct/VkS/members/bad", "entry must be an object"},
+		{"/struct/VkS/members/m~1x/bogus", "unknown field"},
+		{"/struct/VkS/members/m~1x/hide", "must be a boolean"},
+		{"/struct/VkT/members", "must be an object"},
+		{"/union", "section must be an object"},
+	}
+
+	if got := ValidateExceptions(gjson.Parse(doc)); !reflect.DeepEqual(got, want) {
+		t.Errorf("ValidateExceptions returned")
+		for _, p := range got {
+			t.Errorf("  %s", p)
+		}
+		t.Errorf("want")
+		for _, p := range want {
+			t.Errorf("  %s", p)
+		}
+	}
+
+	if got := ValidateExceptions(gjson.Parse(`[]`)); len(got) != 1 || got[0].Path != "" {
+		t.Errorf("ValidateExceptions of an array returned %v", got)
+	}
+}
+
+// TestValidateRepositoryExceptions checks that the exceptions.json shipped with vk-gen matches the schema
+func TestValidateRepositoryExceptions(t *testing.T) {
+	data, err := os.ReadFile("../exceptions.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if problems := ValidateExceptions(gjson.ParseBytes(data)); len(problems) > 0 {
+		t.Errorf("exceptions.json has %d problem(s), first: %s", len(problems), problems[0])
+	}
+}
+
+func TestUnusedExceptions(t *testing.T) {
+	doc := gjson.Parse(`{
+		"struct": {"VkUsed": {}, "VkUnused": {}, "!comment": "ignored"},
+		"command": {"vkUnused/x": {}},
+		"platform": {"win32": {}, "VkUsed": {}},
+		"unknown": {"VkUnused": {}}
+	}`)
+	used := map[string]bool{"VkUsed": true, "win32": true}
+	usedPlatforms := map[string]bool{"win32": true}
+
+	want := []string{"/command/vkUnused~1x", "/platform/VkUsed", "/struct/VkUnused"}
+	if got := unusedExceptions(doc, used, usedPlatforms); !reflect.DeepEqual(got, want) {
+		t.Errorf("unusedExceptions returned %v, want %v", got, want)
+	}
+}
+
+// TestGenerateUnusedExceptions checks that an entry matching nothing in the registry is reported in the result, and
+// fails generation with Strict set
+func TestGenerateUnusedExceptions(t *testing.T) {
+	base, err := os.ReadFile("../exceptions.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	overlay := `{
+		"struct": {"VkNoSuchStruct": {"publicName": "X"}, "VkApplicationInfo": {"publicName": "ApplicationInfo"}},
+		"platform": {"nosuch": {"go:build": "nosuch"}}
+	}`
+
+	for _, strict := range []bool{false, true} {
+		opts := Options{
+			Exceptions: [][]byte{base, []byte(overlay)},
+			StaticDir:  "../static_include",
+			Output:     NewMemFS(),
+			Strict:     strict,
+			Targets:    []Target{{RegistryFile: "testdata/vk.xml", OutDir: "vulkan", Api: "vulkan"}},
+		}
+
+		res, err := Generate(context.Background(), opts)
+		if strict != (err != nil) || (err != nil && !strings.Contains(err.Error(), "did not match any registry element")) {
+			t.Errorf("strict %v: Generate returned error %v", strict, err)
+		}
+
+		found := make(map[string]bool)
+		for _, p := range res.UnusedExceptions {
+			found[p] = true
+		}
+		for _, p := range []string{"/struct/VkNoSuchStruct", "/platform/nosuch"} {
+			if !found[p] {
+				t.Errorf("strict %v: UnusedExceptions does not contain %s", strict, p)
+			}
+		}
+		if found["/struct/VkApplicationInfo"] {
+			t.Errorf("strict %v: UnusedExceptions contains an entry matching a registry type", strict)
+		}
+	}
+}
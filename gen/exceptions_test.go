@@ -0,0 +1,132 @@
+package gen
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// TestLoadExceptionsSyntax checks that a malformed layer is reported with the offset of the error, whether it is the
+// only layer or one of several
+func TestLoadExceptionsSyntax(t *testing.T) {
+	cases := []struct {
+		name, layer, offset string
+	}{
+		{"missing comma", `{"handle": {"VkDevice": {"publicName": "X" "comment": 5}}}`, "offset 44"},
+		{"truncated", `{"handle": {"VkDevice": {"publicName": "X"`, "offset 42"},
+	}
+
+	for _, c := range cases {
+		for _, layers := range [][][]byte{{[]byte(c.layer)}, {[]byte(`{}`), []byte(c.layer)}} {
+			_, err := loadExceptions(&Options{Exceptions: layers})
+			if err == nil {
+				t.Errorf("%s, %d layer(s): no error", c.name, len(layers))
+				continue
+			}
+			if !strings.Contains(err.Error(), c.offset) {
+				t.Errorf("%s, %d layer(s): error %q does not report %s", c.name, len(layers), err, c.offset)
+			}
+		}
+	}
+}
+
+func TestMergeExceptions(t *testing.T) {
+	cases := []struct {
+		name   string
+		layers []string
+		want   string
+	}{
+		{
+			"new entry",
+			[]string{`{"handle": {"VkDevice": {"publicName": "Device"}}}`, `{"handle": {"VkQueue": {"publicName": "Q"}}}`},
+			`{"handle": {"VkDevice": {"publicName": "Device"}, "VkQueue": {"publicName": "Q"}}}`,
+		},
+		{
+			"nested merge",
+			[]string{
+				`{"struct": {"VkApplicationInfo": {"publicName": "AppInfo", "members": {"pNext": {"hidden": true}, "apiVersion": {"publicName": "Api"}}}}}`,
+				`{"struct": {"VkApplicationInfo": {"members": {"apiVersion": {"publicName": "Version"}, "pApplicationName": {"publicName": "Name"}}}}}`,
+			},
+			`{"struct": {"VkApplicationInfo": {"publicName": "AppInfo", "members": {"pNext": {"hidden": true}, "apiVersion": {"publicName": "Version"}, "pApplicationName": {"publicName": "Name"}}}}}`,
+		},
+		{
+			"null removes an entry and a field",
+			[]string{
+				`{"basetype": {"A": {"go:type": "int"}, "B": {"go:type": "uint"}}, "handle": {"VkDevice": {"publicName": "Dev", "comment": "c"}}}`,
+				`{"basetype": {"A": null}, "handle": {"VkDevice": {"comment": null}}}`,
+			},
+			`{"basetype": {"B": {"go:type": "uint"}}, "handle": {"VkDevice": {"publicName": "Dev"}}}`,
+		},
+		{
+			"string replaces an object",
+			[]string{`{"define": {"VK_X": {"publicName": "X"}}}`, `{"define": {"VK_X": "!ignore"}}`},
+			`{"define": {"VK_X": "!ignore"}}`,
+		},
+		{
+			"object replaces a string",
+			[]string{`{"define": {"VK_X": "!ignore"}}`, `{"define": {"VK_X": {"publicName": "X"}}}`},
+			`{"define": {"VK_X": {"publicName": "X"}}}`,
+		},
+		{
+			"array replaces an array",
+			[]string{`{"platform": {"win32": {"go:imports": ["a", "b"]}}}`, `{"platform": {"win32": {"go:imports": ["c"]}}}`},
+			`{"platform": {"win32": {"go:imports": ["c"]}}}`,
+		},
+	}
+
+	for _, c := range cases {
+		layers := make([][]byte, len(c.layers))
+		for i, l := range c.layers {
+			layers[i] = []byte(l)
+		}
+
+		got, err := MergeExceptions(layers...)
+		if err != nil {
+			t.Errorf("%s: %v", c.name, err)
+			continue
+		}
+
+		var gotDoc, wantDoc interface{}
+		if err := json.Unmarshal(got, &gotDoc); err != nil {
+			t.Fatal(err)
+		}
+		if err := json.Unmarshal([]byte(c.want), &wantDoc); err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(gotDoc, wantDoc) {
+			t.Errorf("%s: merged to %s, want %s", c.name, got, c.want)
+		}
+	}
+}
+
+// TestHandleOverlay checks that a handle entry for a registry handle only changes the fields it sets
+func TestHandleOverlay(t *testing.T) {
+	base, err := os.ReadFile("../exceptions.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for overlay, want := range map[string]string{
+		`{"handle": {"VkDevice": {"publicName": "Dev"}}}`: "type Dev handle\n",
+		`{"handle": {"VkDevice": {"comment": "x"}}}`:      "// Device: x\n",
+	} {
+		opts := Options{
+			Exceptions: [][]byte{base, []byte(overlay)},
+			StaticDir:  "../static_include",
+			Output:     NewMemFS(),
+			Targets:    []Target{{RegistryFile: "testdata/vk.xml", OutDir: "vulkan", Api: "vulkan"}},
+		}
+		if _, err := Generate(context.Background(), opts); err != nil {
+			t.Errorf("%s: %v", overlay, err)
+			continue
+		}
+
+		data, _ := opts.Output.(*MemFS).ReadFile("vulkan/handle.go")
+		if !strings.Contains(string(data), want) {
+			t.Errorf("%s: handle.go does not contain %q", overlay, want)
+		}
+	}
+}
@@ -0,0 +1,28 @@
+package gen
+
+import (
+	"fmt"
+	"io/fs"
+
+	"github.com/sirupsen/logrus"
+)
+
+func (g *generator) copyStaticFiles() error {
+	logrus.Info("Copying static files")
+
+	err := fs.WalkDir(g.opts.Static, ".", func(name string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		data, err := fs.ReadFile(g.opts.Static, name)
+		if err != nil {
+			return err
+		}
+		return g.writeFile(name, data)
+	})
+
+	if err != nil {
+		return fmt.Errorf("could not copy static files: %w", err)
+	}
+	return nil
+}
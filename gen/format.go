@@ -0,0 +1,137 @@
+package gen
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/scanner"
+	"go/token"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/bbredesen/vk-gen/def"
+	"github.com/sirupsen/logrus"
+)
+
+// typeSpan records where the code for one type was printed in a generated file, so that a syntax error can be
+// reported with the type that produced it
+type typeSpan struct {
+	registryName string
+	start, end   int // Byte offsets in the file
+}
+
+// formatSource finishes a generated file: it removes declarations replaced by overrides, imports each package in
+// candidates that the file refers to, and formats the result with go/format. If src is not valid Go, the error names
+// the type that printed the offending code, and src is returned as-is so that it can still be written and inspected.
+func (g *generator) formatSource(filename string, src []byte, candidates def.ImportMap, spans []typeSpan) ([]byte, error) {
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, filename, src, parser.ParseComments)
+	if err != nil {
+		return src, syntaxError(filename, err, spans)
+	}
+
+	g.applyOverrides(filename, f)
+
+	buf := &bytes.Buffer{}
+	if err := format.Node(buf, fset, f); err != nil {
+		return src, fmt.Errorf("could not format %s: %w", filename, err)
+	}
+
+	out := insertImports(buf.Bytes(), usedImports(f, candidates))
+	formatted, err := format.Source(out)
+	if err != nil {
+		return out, fmt.Errorf("could not format %s: %w", filename, err)
+	}
+	return formatted, nil
+}
+
+// syntaxError logs each parse error, with the type whose code contains it if it can be found, and returns an error
+// naming those types
+func syntaxError(filename string, err error, spans []typeSpan) error {
+	list, ok := err.(scanner.ErrorList)
+	if !ok {
+		return fmt.Errorf("generated code in %s is not valid Go: %w", filename, err)
+	}
+
+	names := make([]string, 0)
+	for _, e := range list {
+		entry := logrus.WithField("file", filename).
+			WithField("line", e.Pos.Line).
+			WithField("error", e.Msg)
+		for _, s := range spans {
+			if e.Pos.Offset >= s.start && e.Pos.Offset < s.end {
+				entry = entry.WithField("registry name", s.registryName)
+				if len(names) == 0 || names[len(names)-1] != s.registryName {
+					names = append(names, s.registryName)
+				}
+				break
+			}
+		}
+		entry.Error("Generated code is not valid Go")
+	}
+
+	if len(names) == 0 {
+		return fmt.Errorf("generated code in %s is not valid Go: %w", filename, list[0])
+	}
+	return fmt.Errorf("generated code for %s in %s is not valid Go: %w", strings.Join(names, ", "), filename, list[0])
+}
+
+// usedImports returns the paths in candidates that f refers to and does not already import, sorted. A package is
+// referred to if its name (the last element of the path) is used as the qualifier of a selector and is not declared
+// in the file, e.g. unsafe.Pointer.
+func usedImports(f *ast.File, candidates def.ImportMap) []string {
+	imported := make(map[string]bool)
+	for _, spec := range f.Imports {
+		imported[strings.Trim(spec.Path.Value, `"`)] = true
+	}
+
+	qualifiers := make(map[string]bool)
+	ast.Inspect(f, func(n ast.Node) bool {
+		if sel, ok := n.(*ast.SelectorExpr); ok {
+			if id, ok := sel.X.(*ast.Ident); ok && id.Obj == nil {
+				qualifiers[id.Name] = true
+			}
+		}
+		return true
+	})
+
+	rval := make([]string, 0)
+	for p := range candidates {
+		if !imported[p] && qualifiers[path.Base(p)] {
+			rval = append(rval, p)
+		}
+	}
+	sort.Strings(rval)
+	return rval
+}
+
+var packageClause = regexp.MustCompile(`(?m)^package \w+\n`)
+
+// insertImports adds an import declaration after the package clause of src
+func insertImports(src []byte, imports []string) []byte {
+	if len(imports) == 0 {
+		return src
+	}
+	loc := packageClause.FindIndex(src)
+	if loc == nil {
+		return src
+	}
+
+	sb := &bytes.Buffer{}
+	sb.Write(src[:loc[1]])
+	if len(imports) == 1 {
+		sb.WriteString("\nimport \"" + imports[0] + "\"\n")
+	} else {
+		sb.WriteString("\nimport (\n")
+		for _, p := range imports {
+			sb.WriteString("\t\"" + p + "\"\n")
+		}
+		sb.WriteString(")\n")
+	}
+	sb.Write(src[loc[1]:])
+	return sb.Bytes()
+}
@@ -0,0 +1,33 @@
+package gen
+
+import (
+	"context"
+	"os"
+	"strings"
+	"testing"
+)
+
+// TestSyntaxErrorFailsGenerate checks that an exception producing invalid Go fails generation, and that the error
+// names the type that printed the code
+func TestSyntaxErrorFailsGenerate(t *testing.T) {
+	base, err := os.ReadFile("../exceptions.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	overlay := `{"struct": {"VkApplicationInfo": {"members": {"applicationVersion": {"publicName": "App Version"}}}}}`
+
+	opts := Options{
+		Exceptions: [][]byte{base, []byte(overlay)},
+		StaticDir:  "../static_include",
+		Output:     NewMemFS(),
+		Targets:    []Target{{RegistryFile: "testdata/vk.xml", OutDir: "vulkan", Api: "vulkan"}},
+	}
+
+	_, err = Generate(context.Background(), opts)
+	if err == nil {
+		t.Fatal("Generate succeeded with invalid generated code")
+	}
+	if !strings.Contains(err.Error(), "VkApplicationInfo") {
+		t.Errorf("error does not name the type: %v", err)
+	}
+}
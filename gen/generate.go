@@ -0,0 +1,429 @@
+package gen
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/template"
+
+	"github.com/antchfx/xmlquery"
+	"github.com/bbredesen/vk-gen/def"
+	"github.com/bbredesen/vk-gen/feat"
+	"github.com/sirupsen/logrus"
+	"github.com/tidwall/gjson"
+)
+
+// Generate reads the registry and exceptions files and writes a go-vk package for each target in opts. Each
+// registry file is parsed once, no matter how many targets read it. Generation stops at the first target that
+// fails, or when ctx is cancelled.
+func Generate(ctx context.Context, opts Options) (Result, error) {
+	opts.setDefaults()
+	rval := Result{}
+
+	if len(opts.Targets) == 0 {
+		return rval, errors.New("no targets to generate")
+	}
+
+	outDirs := make(map[string]bool)
+	for _, t := range opts.Targets {
+		dir := filepath.Clean(t.OutDir)
+		if outDirs[dir] {
+			return rval, fmt.Errorf("output directory %s is used by more than one target", t.OutDir)
+		}
+		outDirs[dir] = true
+	}
+
+	jsonDoc, err := loadExceptions(&opts)
+	if err != nil {
+		return rval, err
+	}
+
+	rval.ExceptionProblems = ValidateExceptions(jsonDoc)
+	for _, p := range rval.ExceptionProblems {
+		logrus.WithField("path", p.Path).Warn("Invalid exceptions entry: " + p.Message)
+	}
+	if opts.Strict && len(rval.ExceptionProblems) > 0 {
+		return rval, fmt.Errorf("exceptions failed validation with %d problem(s), first: %s", len(rval.ExceptionProblems), rval.ExceptionProblems[0])
+	}
+
+	var overrides *overrideSet
+	if opts.Overrides != nil {
+		if overrides, err = loadOverrides(opts.Overrides); err != nil {
+			return rval, err
+		}
+	}
+
+	var templates *template.Template
+	if opts.UseTemplates {
+		if templates, err = loadTemplates(opts.Templates); err != nil {
+			return rval, err
+		}
+	}
+
+	// The parsed documents are never modified, so they are safe to share between targets
+	xmlDocs := make(map[string]*xmlquery.Node)
+	xmlSums := make(map[string][]byte)
+	for _, t := range opts.Targets {
+		if _, found := xmlDocs[t.RegistryFile]; !found {
+			var err error
+			if xmlDocs[t.RegistryFile], xmlSums[t.RegistryFile], err = readRegistry(t.RegistryFile); err != nil {
+				return rval, err
+			}
+		}
+	}
+
+	usedNames, usedPlatforms := make(map[string]bool), make(map[string]bool)
+
+	for i, t := range opts.Targets {
+		if err := ctx.Err(); err != nil {
+			return rval, err
+		}
+
+		logrus.WithField("api", t.Api).
+			WithField("registry", t.RegistryFile).
+			WithField("directory", t.OutDir).
+			Infof("Generating target %d of %d", i+1, len(opts.Targets))
+
+		g := &generator{
+			ctx:     ctx,
+			target:  t,
+			opts:    &opts,
+			xmlDoc:  xmlDocs[t.RegistryFile],
+			jsonDoc: jsonDoc,
+			result:  TargetResult{OutDir: t.OutDir},
+
+			inputHash: inputHash(xmlSums[t.RegistryFile], jsonDoc),
+
+			overrides: overrides,
+			templates: templates,
+
+			usedNames:     usedNames,
+			usedPlatforms: usedPlatforms,
+		}
+		err := g.run()
+		rval.Targets = append(rval.Targets, g.result)
+		if err != nil {
+			return rval, fmt.Errorf("target %s: %w", t.OutDir, err)
+		}
+	}
+
+	rval.UnusedExceptions = unusedExceptions(jsonDoc, usedNames, usedPlatforms)
+	for _, p := range rval.UnusedExceptions {
+		logrus.WithField("path", p).Warn("Exceptions entry did not match any registry element")
+	}
+	if opts.Strict && len(rval.UnusedExceptions) > 0 {
+		return rval, fmt.Errorf("%d exceptions entries did not match any registry element, first: %s", len(rval.UnusedExceptions), rval.UnusedExceptions[0])
+	}
+
+	return rval, nil
+}
+
+// loadExceptions reads and merges every exceptions layer in opts. Each layer must be valid JSON, since gjson
+// silently accepts (and misreads) malformed documents.
+func loadExceptions(opts *Options) (gjson.Result, error) {
+	layers := append([][]byte{}, opts.Exceptions...)
+	names := make([]string, 0, len(layers))
+	for i := range opts.Exceptions {
+		names = append(names, fmt.Sprintf("exceptions layer %d", i+1))
+	}
+	for _, filename := range opts.ExceptionsFiles {
+		data, err := os.ReadFile(filename)
+		if err != nil {
+			return gjson.Result{}, fmt.Errorf("could not read exceptions file: %w", err)
+		}
+		layers = append(layers, data)
+		names = append(names, filename)
+	}
+
+	for i, layer := range layers {
+		if err := checkExceptionsSyntax(layer); err != nil {
+			return gjson.Result{}, fmt.Errorf("%s: %w", names[i], err)
+		}
+	}
+
+	if len(layers) == 1 {
+		return gjson.ParseBytes(layers[0]), nil
+	}
+
+	merged, err := MergeExceptions(layers...)
+	if err != nil {
+		return gjson.Result{}, err
+	}
+	return gjson.ParseBytes(merged), nil
+}
+
+// readRegistry parses the registry, also returning the SHA-256 of the file's content
+func readRegistry(filename string) (*xmlquery.Node, []byte, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, nil, fmt.Errorf("could not open Vulkan registry file: %w", err)
+	}
+
+	xmlDoc, err := xmlquery.Parse(bytes.NewReader(data))
+	if err != nil {
+		return nil, nil, fmt.Errorf("could not parse XML from %s: %w", filename, err)
+	}
+	sum := sha256.Sum256(data)
+	return xmlDoc, sum[:], nil
+}
+
+// inputHash identifies the inputs to a target in the generated file headers: the registry file and the merged
+// exceptions. It is printed in place of a timestamp, so that regenerating from the same inputs gives identical files.
+func inputHash(registrySum []byte, jsonDoc gjson.Result) string {
+	h := sha256.New()
+	h.Write(registrySum)
+	h.Write([]byte(jsonDoc.Raw))
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// headerVersion returns the value of VK_HEADER_VERSION for the api, or "unknown" if the registry does not define it
+func headerVersion(xmlDoc *xmlquery.Node, api string) string {
+	n := xmlquery.FindOne(xmlDoc, fmt.Sprintf("//types/type[name='VK_HEADER_VERSION' and %s]", def.ApiPredicate("api", api)))
+	if n == nil {
+		return "unknown"
+	}
+	fields := strings.Fields(n.InnerText())
+	return fields[len(fields)-1]
+}
+
+// generator holds the state for generating a single target. Type and value registries are created fresh for each
+// generator.
+type generator struct {
+	ctx     context.Context
+	target  Target
+	opts    *Options
+	xmlDoc  *xmlquery.Node
+	jsonDoc gjson.Result
+
+	// headerVersion and inputHash are printed in the header of every generated file
+	headerVersion, inputHash string
+	overrides                *overrideSet
+	templates                *template.Template // nil unless Options.UseTemplates is set
+
+	result  TargetResult
+	written []string // Files written for the target, relative to OutDir
+
+	// usedNames and usedPlatforms collect, across all targets, the registry names that an exceptions entry may
+	// refer to: every type, command and enum named in the XML registry, plus every type in the generated output
+	usedNames, usedPlatforms map[string]bool
+}
+
+// run generates the target. Some inconsistencies in the registry are only detected deep inside the def package,
+// which panics; those are recovered and returned as an error.
+func (g *generator) run() (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("generation failed: %v", r)
+		}
+	}()
+
+	t := &g.target
+	g.headerVersion = headerVersion(g.xmlDoc, t.Api)
+
+	if len(t.Platforms) == 0 {
+		logrus.Info("Generating core Vulkan only; no platform specific extensions will be available!")
+	} else {
+		logrus.WithField("platforms", t.Platforms).Infof("Found %d platforms to generate for", len(t.Platforms))
+	}
+
+	globalTypes := make(def.TypeRegistry)
+	globalValues := make(def.ValueRegistry)
+
+	for name := range registryNames(g.xmlDoc) {
+		g.usedNames[name] = true
+	}
+
+	pm := def.ReadPlatformsFromXML(g.xmlDoc)
+	def.ReadPlatformExceptionsFromJSON(g.jsonDoc, pm)
+
+	for tc := def.CatNone; tc < def.CatMaximum; tc++ {
+		xml, json := tc.ReadFns()
+		if xml != nil {
+			xml(g.xmlDoc, globalTypes, globalValues, t.Api)
+		}
+		if json != nil {
+			json(g.jsonDoc, globalTypes, globalValues)
+		}
+	}
+
+	platforms := make(feat.PlatformRegistry)
+	// static platform
+	platforms[""] = feat.NewGeneralPlatform()
+	for _, n := range xmlquery.Find(g.xmlDoc, "//platforms/platform") {
+		plat := feat.NewPlatformFromXML(n)
+		platforms[plat.Name()] = plat
+		g.usedPlatforms[plat.Name()] = true
+	}
+	g.jsonDoc.Get("platform").ForEach(func(key, value gjson.Result) bool {
+		if key.String() == "!comment" {
+			return true
+		}
+		r := feat.NewOrUpdatePlatformFromJSON(key.String(), value, platforms[key.String()])
+		platforms[r.Name()] = r
+		return true
+	})
+
+	featureNodes, err := feat.FindFeatureNodesForApi(g.xmlDoc, t.Api, t.ApiVersion)
+	if err != nil {
+		return fmt.Errorf("could not select features for the requested API version: %w", err)
+	}
+	if len(featureNodes) == 0 {
+		return fmt.Errorf("no features found in the registry for api %s", t.Api)
+	}
+
+	// Features are merged in dependency order, so coreFeature holds every require list up to the target version, and
+	// removals made by a later feature (e.g., VKSC_VERSION_1_0 removing parts of VK_VERSION_1_2) are applied last
+	coreFeature := feat.NewFeature()
+	coreVersions := make(map[string]bool)
+	var targetFeature *feat.Feature
+	for _, node := range featureNodes {
+		targetFeature = feat.ReadFeatureFromXML(node, globalTypes, globalValues, t.Api)
+		coreFeature.MergeWith(targetFeature)
+		coreVersions[targetFeature.Name()] = true
+	}
+	g.result.Feature = targetFeature.Name()
+	logrus.WithField("feature", targetFeature.Name()).
+		WithField("version", targetFeature.Version()).
+		Infof("Generating for %s core version %s", t.Api, targetFeature.Version())
+
+	// Manually include external types
+	coreFeature.MergeIncludeSet(globalTypes.SelectCategory(def.CatExternal))
+
+	candidateExtensions := make(map[string]*feat.Extension)
+	readExtensions := func(query string) error {
+		for _, extNode := range xmlquery.Find(g.xmlDoc, query) {
+			ext, err := feat.ReadExtensionFromXML(extNode, globalTypes, globalValues, t.Api)
+			if err != nil {
+				return err
+			}
+			candidateExtensions[ext.Name()] = ext
+		}
+		return nil
+	}
+
+	for _, platName := range t.Platforms {
+		if err := readExtensions(fmt.Sprintf("//extension[@platform='%s' and %s]", platName, def.ApiPredicate("supported", t.Api))); err != nil {
+			return err
+		}
+	}
+
+	// "Core" extensions
+	if err := readExtensions(fmt.Sprintf("//extension[not(@platform) and %s]", def.ApiPredicate("supported", t.Api))); err != nil {
+		return err
+	}
+
+	extFilter := feat.NewExtensionFilter(t.IncludeExtensions, t.ExcludeExtensions)
+	selectedExtensions := feat.SelectExtensions(candidateExtensions, extFilter, coreVersions)
+	logrus.Infof("Generating %d of %d available extensions", len(selectedExtensions), len(candidateExtensions))
+
+	for k := range selectedExtensions {
+		g.result.Extensions = append(g.result.Extensions, k)
+	}
+	sort.Strings(g.result.Extensions)
+
+	// Conditional require blocks can only be evaluated once the full set of versions and extensions is known
+	isAvailable := func(name string) bool {
+		return coreVersions[name] || selectedExtensions[name] != nil
+	}
+	coreFeature.ResolveConditionalRequires(isAvailable)
+
+	for _, ext := range selectedExtensions {
+		ext.ResolveConditionalRequires(isAvailable)
+		platforms[ext.PlatformName()].IncludeExtension(ext)
+	}
+
+	coreFeature.MarkDeprecated(globalTypes)
+	feat.MarkDeprecatedExtensions(coreFeature, selectedExtensions, isAvailable, globalTypes, globalValues)
+
+	coreFeature.MergeWith(platforms[""].GeneratePlatformFeatures())
+
+	coreFeature.Resolve(globalTypes, globalValues)
+	for k := range coreFeature.ResolvedTypes {
+		g.usedNames[k] = true
+	}
+
+	// Platform command indices follow the core commands, so platforms must be printed in a stable order
+	platformNames := make([]string, 0, len(platforms))
+	for pName := range platforms {
+		if pName != "" {
+			platformNames = append(platformNames, pName)
+		}
+	}
+	sort.Strings(platformNames)
+
+	// Platforms are resolved before anything is printed, so that the String method of a core enum can name the values
+	// that a platform adds to it
+	platformFeatures := make(map[string]*feat.Feature, len(platformNames))
+	for _, pName := range platformNames {
+		pf := platforms[pName].GeneratePlatformFeatures()
+		pf.Resolve(globalTypes, globalValues)
+		for k := range pf.ResolvedTypes {
+			g.usedNames[k] = true
+		}
+		for typeName, vals := range pf.ResolvedValues {
+			if td, found := globalTypes[typeName]; found && pf.ResolvedTypes[typeName] == nil {
+				def.AddPlatformValues(td, vals)
+			}
+		}
+		platformFeatures[pName] = pf
+	}
+
+	commandCount := 0
+
+	coreByCategory := coreFeature.FilterByCategory()
+	for _, tc := range feat.SortedCategories(coreByCategory) {
+		reg := coreByCategory[tc]
+		if err := g.ctx.Err(); err != nil {
+			return err
+		}
+
+		if tc == def.CatHandle {
+			// Special case...VK_NULL_HANDLE is included by vk.xml as a type, not an enum. vk-gen treats it as a
+			// ValueDefiner, so it must be manually added to the feature registry.
+			globalValues["VK_NULL_HANDLE"].Resolve(globalTypes, globalValues)
+			reg.ResolvedTypes["VK_DEFINE_HANDLE"].PushValue(globalValues["VK_NULL_HANDLE"])
+		}
+
+		if err := g.printCategory(tc, reg, nil, 0); err != nil {
+			return err
+		}
+		if tc == def.CatCommand {
+			commandCount += len(reg.ResolvedTypes)
+		}
+
+	}
+
+	for _, pName := range platformNames {
+		plat := platforms[pName]
+
+		pfByCategory := platformFeatures[pName].FilterByCategory()
+		for _, tc := range feat.SortedCategories(pfByCategory) {
+			reg := pfByCategory[tc]
+			if err := g.printCategory(tc, reg, plat, commandCount); err != nil {
+				return err
+			}
+			if tc == def.CatCommand {
+				commandCount += len(reg.ResolvedTypes)
+			}
+		}
+	}
+
+	if err := g.printApiVersion(targetFeature); err != nil {
+		return err
+	}
+
+	if err := g.copyStaticFiles(); err != nil {
+		return err
+	}
+	if err := g.copyOverrideFiles(); err != nil {
+		return err
+	}
+	return g.writeManifest()
+}
@@ -0,0 +1,97 @@
+package gen
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+)
+
+const manifestHeader = "# Files generated by vk-gen. A file listed here is removed when a later run no longer generates it;\n" +
+	"# files that are not listed are never touched.\n"
+
+// parseManifest returns the file names listed in a manifest. Names that could refer to a file outside the output
+// directory are dropped, in case the manifest was edited by hand.
+func parseManifest(data []byte) []string {
+	rval := make([]string, 0)
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		name := strings.TrimSpace(scanner.Text())
+		if name == "" || strings.HasPrefix(name, "#") {
+			continue
+		}
+		if !fs.ValidPath(name) || name == "." || name == ManifestFile {
+			logrus.WithField("entry", name).Warn("Ignoring invalid entry in " + ManifestFile)
+			continue
+		}
+		rval = append(rval, name)
+	}
+	return rval
+}
+
+// generatedNames returns the files written so far for the target, relative to its OutDir and sorted
+func (g *generator) generatedNames() []string {
+	rval := make([]string, 0, len(g.written))
+	for _, name := range g.written {
+		if name != ManifestFile {
+			rval = append(rval, name)
+		}
+	}
+	sort.Strings(rval)
+	return rval
+}
+
+// staleFiles returns the files listed in the previous manifest that are not in generated
+func staleFiles(previous []byte, generated []string) []string {
+	current := make(map[string]bool, len(generated))
+	for _, name := range generated {
+		current[name] = true
+	}
+
+	rval := make([]string, 0)
+	for _, name := range parseManifest(previous) {
+		if !current[name] {
+			rval = append(rval, name)
+		}
+	}
+	sort.Strings(rval)
+	return rval
+}
+
+// writeManifest removes files left over from the previous run, if the output supports it, and then writes the
+// manifest for this run. Stale files are removed first, so that a failure leaves them listed in the old manifest.
+func (g *generator) writeManifest() error {
+	generated := g.generatedNames()
+	manifestPath := path.Join(filepath.ToSlash(g.target.OutDir), ManifestFile)
+
+	if pfs, ok := g.opts.Output.(PruneFS); ok {
+		previous, err := pfs.ReadFile(manifestPath)
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return fmt.Errorf("could not read previous manifest: %w", err)
+		}
+
+		for _, name := range staleFiles(previous, generated) {
+			outpath := path.Join(filepath.ToSlash(g.target.OutDir), name)
+			if err := pfs.Remove(outpath); err != nil && !errors.Is(err, fs.ErrNotExist) {
+				return fmt.Errorf("could not remove stale file %s: %w", outpath, err)
+			}
+			logrus.WithField("file", outpath).Info("Removed file that is no longer generated")
+			g.result.Removed = append(g.result.Removed, outpath)
+		}
+	}
+
+	sb := &strings.Builder{}
+	sb.WriteString(manifestHeader)
+	for _, name := range generated {
+		sb.WriteString(name)
+		sb.WriteByte('\n')
+	}
+	return g.writeFile(ManifestFile, []byte(sb.String()))
+}
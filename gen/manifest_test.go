@@ -0,0 +1,69 @@
+package gen
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestParseManifest(t *testing.T) {
+	data := manifestHeader + "a.go\n\n  b.go  \nsub/c.go\n../x\n/abs\nsub/../d.go\n.\n" + ManifestFile + "\n# comment\n"
+
+	want := []string{"a.go", "b.go", "sub/c.go"}
+	if got := parseManifest([]byte(data)); !reflect.DeepEqual(got, want) {
+		t.Errorf("parseManifest returned %v, want %v", got, want)
+	}
+}
+
+// TestWriteManifest checks that only files listed in the previous manifest and no longer generated are removed
+func TestWriteManifest(t *testing.T) {
+	root := t.TempDir()
+	disk := DiskFS{Root: root}
+	for name, data := range map[string]string{
+		"vk/kept.go":         "generated again",
+		"vk/old.go":          "listed, no longer generated",
+		"vk/sub/old.go":      "listed, no longer generated",
+		"vk/user.go":         "never listed",
+		"x":                  "outside the output directory",
+		"vk/" + ManifestFile: manifestHeader + "kept.go\nold.go\nsub/old.go\n../x\n/abs\n" + ManifestFile + "\n",
+	} {
+		if err := disk.WriteFile(name, []byte(data)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	g := &generator{
+		target:  Target{OutDir: "vk"},
+		opts:    &Options{Output: disk},
+		written: []string{"kept.go", "new.go"},
+	}
+	if err := g.writeManifest(); err != nil {
+		t.Fatal(err)
+	}
+
+	if want := []string{"vk/old.go", "vk/sub/old.go"}; !reflect.DeepEqual(g.result.Removed, want) {
+		t.Errorf("removed %v, want %v", g.result.Removed, want)
+	}
+	for name, exists := range map[string]bool{
+		"vk/kept.go":         true,
+		"vk/old.go":          false,
+		"vk/sub/old.go":      false,
+		"vk/user.go":         true,
+		"x":                  true,
+		"vk/" + ManifestFile: true,
+	} {
+		_, err := os.Stat(filepath.Join(root, filepath.FromSlash(name)))
+		if got := err == nil; got != exists {
+			t.Errorf("%s exists: %v, want %v", name, got, exists)
+		}
+	}
+
+	manifest, err := disk.ReadFile("vk/" + ManifestFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := manifestHeader + "kept.go\nnew.go\n"; string(manifest) != want {
+		t.Errorf("manifest is\n%s\nwant\n%s", manifest, want)
+	}
+}
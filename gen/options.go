@@ -0,0 +1,120 @@
+// Package gen generates go-vk bindings from the Vulkan XML registry. It holds everything the vk-gen command does,
+// so that the generator can be called from other Go tools (e.g., a go:generate helper) and from tests.
+package gen
+
+import (
+	"io/fs"
+	"os"
+)
+
+// Target describes one generated package. A single call to Generate may produce several targets, e.g. the vulkan
+// and vulkansc variants of the same registry, or bindings for two registry versions. Each target is read and
+// resolved into its own registries; only the parsed registry and exceptions documents are shared between targets.
+type Target struct {
+	// RegistryFile is the path of the Vulkan XML registry (vk.xml) to read
+	RegistryFile string
+	// OutDir is the directory that the go-vk package is written to. It is created if it does not exist.
+	OutDir string
+	// Api is the API to generate, "vulkan" or "vulkansc"
+	Api string
+	// ApiVersion is the core version to generate against, e.g. "1.3". If empty, the latest version in the registry
+	// is used.
+	ApiVersion string
+	// Platforms lists the platforms (by Vulkan name, not GOOS) whose extensions are generated
+	Platforms []string
+	// IncludeExtensions and ExcludeExtensions hold extension names and/or vendor tags; see feat.NewExtensionFilter
+	IncludeExtensions, ExcludeExtensions []string
+}
+
+// Options configures a call to Generate.
+type Options struct {
+	Targets []Target
+
+	// Exceptions holds the content of one or more exceptions files, applied in order and followed by each file in
+	// ExceptionsFiles. See MergeExceptions for how later layers override earlier ones. If both are empty,
+	// "exceptions.json" is read.
+	Exceptions      [][]byte
+	ExceptionsFiles []string
+	// Static holds the static files copied into every target. If nil, they are read from StaticDir, which defaults
+	// to "static_include".
+	Static    fs.FS
+	StaticDir string
+	// Overrides holds hand-written Go files (package vk) that replace generated code. Any top-level type, function,
+	// method, variable or constant declared in an override file is left out of the generated files, along with the
+	// methods of any type it declares, and the override files are copied into every target. If nil and OverridesDir
+	// is set, the files are read from OverridesDir.
+	Overrides    fs.FS
+	OverridesDir string
+	// UseTemplates renders each type's declarations with text/template instead of the built-in Go printers. The
+	// built-in templates produce the same output as the printers; Templates and TemplateDirs add directories of
+	// *.tmpl files that replace built-in templates by name. Setting either of those implies UseTemplates.
+	UseTemplates bool
+	Templates    []fs.FS
+	TemplateDirs []string
+
+	// Strict makes Generate fail if the exceptions do not match the schema, or if any exceptions entry does not
+	// match a registry element in any target. Otherwise, those problems are logged as warnings.
+	Strict bool
+
+	// Output receives the generated files. Defaults to DiskFS, i.e. writing each target to its OutDir on disk.
+	Output OutputFS
+}
+
+// Result describes what was generated, in the same order as Options.Targets.
+type Result struct {
+	Targets []TargetResult
+
+	// ExceptionProblems lists schema violations found in the (merged) exceptions
+	ExceptionProblems []ExceptionProblem
+	// UnusedExceptions holds a JSON pointer to each exceptions entry that did not match a registry element in any
+	// target, e.g. /union/VkClearValue
+	UnusedExceptions []string
+}
+
+// TargetResult describes one generated package.
+type TargetResult struct {
+	OutDir string
+	// Feature is the name of the selected core version feature, e.g. VK_VERSION_1_3
+	Feature string
+	// Extensions holds the names of the generated extensions, sorted
+	Extensions []string
+	// Files holds the name of every file written for the target, as passed to Options.Output
+	Files []string
+	// Removed holds the name of every file from a previous run that was removed because it is no longer generated;
+	// see ManifestFile
+	Removed []string
+}
+
+const (
+	DefaultExceptionsFile = "exceptions.json"
+	DefaultStaticDir      = "static_include"
+
+	// ManifestFile is written to each target's OutDir, listing every file generated or copied there. When the
+	// output is a PruneFS, files listed in the previous manifest that are no longer generated are removed.
+	ManifestFile = "vk-gen-manifest.txt"
+)
+
+func (o *Options) setDefaults() {
+	if len(o.Exceptions) == 0 && len(o.ExceptionsFiles) == 0 {
+		o.ExceptionsFiles = []string{DefaultExceptionsFile}
+	}
+	if o.StaticDir == "" {
+		o.StaticDir = DefaultStaticDir
+	}
+	if o.Static == nil {
+		o.Static = os.DirFS(o.StaticDir)
+	}
+	if o.Overrides == nil && o.OverridesDir != "" {
+		o.Overrides = os.DirFS(o.OverridesDir)
+	}
+	for _, dir := range o.TemplateDirs {
+		o.Templates = append(o.Templates, os.DirFS(dir))
+	}
+	o.TemplateDirs = nil
+	if len(o.Templates) > 0 {
+		o.UseTemplates = true
+	}
+	if o.Output == nil {
+		o.Output = DiskFS{}
+	}
+}
@@ -0,0 +1,165 @@
+package gen
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// OutputFS receives every file written by Generate. Names are slash-separated and include the target's OutDir, so
+// a single OutputFS can hold several targets.
+type OutputFS interface {
+	WriteFile(name string, data []byte) error
+}
+
+// DiskFS writes files to the local filesystem, creating directories as needed. Relative names are resolved against
+// Root, or against the working directory if Root is empty. This is the default output.
+type DiskFS struct {
+	Root string
+}
+
+func (d DiskFS) WriteFile(name string, data []byte) error {
+	p := d.path(name)
+	if err := os.MkdirAll(filepath.Dir(p), 0777); err != nil {
+		return err
+	}
+	return os.WriteFile(p, data, 0666)
+}
+
+func (d DiskFS) ReadFile(name string) ([]byte, error) { return os.ReadFile(d.path(name)) }
+func (d DiskFS) Remove(name string) error             { return os.Remove(d.path(name)) }
+
+func (d DiskFS) path(name string) string {
+	p := filepath.FromSlash(name)
+	if d.Root != "" && !filepath.IsAbs(p) {
+		p = filepath.Join(d.Root, p)
+	}
+	return p
+}
+
+// PruneFS is implemented by outputs that keep the files from earlier runs, such as DiskFS. Generate reads the
+// manifest left by the previous run from a PruneFS, and removes the files it lists that are no longer generated.
+type PruneFS interface {
+	OutputFS
+	ReadFile(name string) ([]byte, error)
+	Remove(name string) error
+}
+
+// MemFS holds generated files in memory, for tests and for comparing output against an existing package.
+type MemFS struct {
+	mu    sync.Mutex
+	files map[string][]byte
+}
+
+func NewMemFS() *MemFS {
+	return &MemFS{files: make(map[string][]byte)}
+}
+
+func (m *MemFS) WriteFile(name string, data []byte) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.files[path.Clean(name)] = append([]byte(nil), data...)
+	return nil
+}
+
+// ReadFile returns the content of a file written to m, and whether it was found.
+func (m *MemFS) ReadFile(name string) ([]byte, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	data, found := m.files[path.Clean(name)]
+	return data, found
+}
+
+// Names returns the name of every file written to m, sorted.
+func (m *MemFS) Names() []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	rval := make([]string, 0, len(m.files))
+	for k := range m.files {
+		rval = append(rval, k)
+	}
+	sort.Strings(rval)
+	return rval
+}
+
+// archiveName strips any leading slash or relative path element, which are not allowed in archive entries.
+func archiveName(name string) string {
+	name = path.Clean("/" + filepath.ToSlash(name))
+	return strings.TrimPrefix(name, "/")
+}
+
+// ZipFS writes files into a zip archive. Close must be called to finish the archive; it does not close the
+// underlying writer.
+type ZipFS struct {
+	w *zip.Writer
+}
+
+func NewZipFS(w io.Writer) *ZipFS {
+	return &ZipFS{w: zip.NewWriter(w)}
+}
+
+func (z *ZipFS) WriteFile(name string, data []byte) error {
+	f, err := z.w.Create(archiveName(name))
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(data)
+	return err
+}
+
+func (z *ZipFS) Close() error { return z.w.Close() }
+
+// TarFS writes files into a tar archive. Close must be called to finish the archive; it does not close the
+// underlying writer. Every entry has the same fixed modification time, so that the archive is reproducible.
+type TarFS struct {
+	w       *tar.Writer
+	modTime time.Time
+}
+
+func NewTarFS(w io.Writer) *TarFS {
+	return &TarFS{w: tar.NewWriter(w), modTime: time.Unix(0, 0)}
+}
+
+func (t *TarFS) WriteFile(name string, data []byte) error {
+	hdr := &tar.Header{
+		Name:    archiveName(name),
+		Mode:    0666,
+		Size:    int64(len(data)),
+		ModTime: t.modTime,
+	}
+	if err := t.w.WriteHeader(hdr); err != nil {
+		return err
+	}
+	_, err := t.w.Write(data)
+	return err
+}
+
+func (t *TarFS) Close() error { return t.w.Close() }
+
+// ConcatFS writes every file to a single stream (e.g., os.Stdout), each preceded by a comment line holding its
+// name.
+type ConcatFS struct {
+	W io.Writer
+}
+
+func (c ConcatFS) WriteFile(name string, data []byte) error {
+	if _, err := fmt.Fprintf(c.W, "// ---- %s ----\n", name); err != nil {
+		return err
+	}
+	if _, err := c.W.Write(data); err != nil {
+		return err
+	}
+	if len(data) > 0 && data[len(data)-1] != '\n' {
+		_, err := fmt.Fprintln(c.W)
+		return err
+	}
+	return nil
+}
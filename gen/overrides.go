@@ -0,0 +1,272 @@
+package gen
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io/fs"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+)
+
+// overrideSet holds the hand-written Go files from Options.Overrides, and the top-level identifiers they declare.
+// Methods are keyed as "Type.Method".
+type overrideSet struct {
+	files map[string][]byte
+	names map[string]string // identifier -> file declaring it
+}
+
+// loadOverrides parses every .go file in fsys. Files that are not valid Go are an error, since they would break the
+// generated package anyway.
+func loadOverrides(fsys fs.FS) (*overrideSet, error) {
+	rval := &overrideSet{
+		files: make(map[string][]byte),
+		names: make(map[string]string),
+	}
+
+	fset := token.NewFileSet()
+	err := fs.WalkDir(fsys, ".", func(name string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() || path.Ext(name) != ".go" {
+			return err
+		}
+		data, err := fs.ReadFile(fsys, name)
+		if err != nil {
+			return err
+		}
+
+		f, err := parser.ParseFile(fset, name, data, parser.SkipObjectResolution)
+		if err != nil {
+			return err
+		}
+		if f.Name.Name != "vk" {
+			logrus.WithField("file", name).
+				WithField("package", f.Name.Name).
+				Warn("Override file is not in package vk")
+		}
+
+		for _, decl := range f.Decls {
+			for _, id := range declNames(decl) {
+				if other, found := rval.names[id]; found {
+					return fmt.Errorf("%s is declared in both %s and %s", id, other, name)
+				}
+				rval.names[id] = name
+			}
+		}
+		rval.files[name] = data
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("could not read overrides: %w", err)
+	}
+
+	logrus.WithField("files", len(rval.files)).
+		WithField("identifiers", len(rval.names)).
+		Info("Loaded overrides")
+	return rval, nil
+}
+
+// declNames returns the identifiers declared by a top-level declaration, ignoring imports and blank identifiers
+func declNames(decl ast.Decl) []string {
+	rval := make([]string, 0)
+	switch d := decl.(type) {
+	case *ast.FuncDecl:
+		rval = append(rval, funcName(d))
+	case *ast.GenDecl:
+		for _, spec := range d.Specs {
+			rval = append(rval, specNames(spec)...)
+		}
+	}
+	return rval
+}
+
+func specNames(spec ast.Spec) []string {
+	rval := make([]string, 0)
+	switch s := spec.(type) {
+	case *ast.TypeSpec:
+		rval = append(rval, s.Name.Name)
+	case *ast.ValueSpec:
+		for _, n := range s.Names {
+			if n.Name != "_" {
+				rval = append(rval, n.Name)
+			}
+		}
+	}
+	return rval
+}
+
+// funcName returns the function's name, or "Type.Method" for a method
+func funcName(d *ast.FuncDecl) string {
+	if d.Recv == nil || len(d.Recv.List) == 0 {
+		return d.Name.Name
+	}
+
+	recv := d.Recv.List[0].Type
+	for {
+		switch r := recv.(type) {
+		case *ast.StarExpr:
+			recv = r.X
+			continue
+		case *ast.IndexExpr:
+			recv = r.X
+			continue
+		case *ast.IndexListExpr:
+			recv = r.X
+			continue
+		case *ast.Ident:
+			return r.Name + "." + d.Name.Name
+		}
+		return d.Name.Name
+	}
+}
+
+func (o *overrideSet) declares(names []string) bool {
+	for _, n := range names {
+		if _, found := o.names[n]; found {
+			return true
+		}
+	}
+	return false
+}
+
+// replacesMethod reports whether name is a method ("Type.Method") whose receiver type is declared by an override
+// file. The generated methods of a replaced type refer to its generated fields, so they are dropped with it.
+func (o *overrideSet) replacesMethod(name string) bool {
+	recv, _, isMethod := strings.Cut(name, ".")
+	return isMethod && o.declares([]string{recv})
+}
+
+// filter removes every top-level declaration in f that is also declared by an override file, along with its
+// comments, and returns the removed identifiers. Methods of a type declared by an override file are removed as well.
+func (o *overrideSet) filter(f *ast.File) []string {
+	removed := make([]string, 0)
+	dropped := make([]ast.Node, 0) // Comments inside these nodes are dropped with them
+
+	decls := f.Decls[:0]
+	for _, decl := range f.Decls {
+		switch d := decl.(type) {
+		case *ast.FuncDecl:
+			if name := funcName(d); o.declares([]string{name}) || o.replacesMethod(name) {
+				removed = append(removed, name)
+				dropped = append(dropped, d)
+				continue
+			}
+
+		case *ast.GenDecl:
+			if d.Tok == token.IMPORT {
+				break
+			}
+			specs := d.Specs[:0]
+			for _, spec := range d.Specs {
+				if names := specNames(spec); o.declares(names) {
+					removed = append(removed, names...)
+					dropped = append(dropped, spec)
+					continue
+				}
+				specs = append(specs, spec)
+			}
+			if len(specs) == 0 {
+				dropped = append(dropped, d)
+				continue
+			}
+			d.Specs = specs
+		}
+		decls = append(decls, decl)
+	}
+
+	if len(removed) == 0 {
+		return removed
+	}
+	f.Decls = decls
+
+	comments := f.Comments[:0]
+	for _, cg := range f.Comments {
+		if !withinAny(cg, dropped) {
+			comments = append(comments, cg)
+		}
+	}
+	f.Comments = comments
+
+	return removed
+}
+
+// withinAny reports whether the comment group lies inside, or is the doc comment of, any of the nodes
+func withinAny(cg *ast.CommentGroup, nodes []ast.Node) bool {
+	for _, n := range nodes {
+		start := n.Pos()
+		switch d := n.(type) {
+		case *ast.FuncDecl:
+			if d.Doc != nil {
+				start = d.Doc.Pos()
+			}
+		case *ast.GenDecl:
+			if d.Doc != nil {
+				start = d.Doc.Pos()
+			}
+		case *ast.TypeSpec:
+			if d.Doc != nil {
+				start = d.Doc.Pos()
+			}
+			if d.Comment != nil && cg == d.Comment {
+				return true
+			}
+		case *ast.ValueSpec:
+			if d.Doc != nil {
+				start = d.Doc.Pos()
+			}
+			if d.Comment != nil && cg == d.Comment {
+				return true
+			}
+		}
+		if cg.Pos() >= start && cg.End() <= n.End() {
+			return true
+		}
+	}
+	return false
+}
+
+// applyOverrides removes declarations replaced by an override file from a generated source file
+func (g *generator) applyOverrides(filename string, f *ast.File) {
+	if g.overrides == nil || len(g.overrides.names) == 0 {
+		return
+	}
+
+	if removed := g.overrides.filter(f); len(removed) > 0 {
+		sort.Strings(removed)
+		logrus.WithField("file", filename).
+			WithField("identifiers", strings.Join(removed, ", ")).
+			Info("Omitted declarations replaced by overrides")
+	}
+}
+
+// copyOverrideFiles writes the override files into the target, next to the generated files
+func (g *generator) copyOverrideFiles() error {
+	if g.overrides == nil {
+		return nil
+	}
+
+	written := make(map[string]bool, len(g.result.Files))
+	for _, f := range g.result.Files {
+		written[f] = true
+	}
+
+	names := make([]string, 0, len(g.overrides.files))
+	for name := range g.overrides.files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		if written[path.Join(filepath.ToSlash(g.target.OutDir), name)] {
+			return fmt.Errorf("override file %s has the same name as a generated file", name)
+		}
+		if err := g.writeFile(name, g.overrides.files[name]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
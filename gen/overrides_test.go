@@ -0,0 +1,38 @@
+package gen
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+// TestOverrideDropsMethods checks that replacing a type also leaves out its generated methods
+func TestOverrideDropsMethods(t *testing.T) {
+	override := "package vk\n\ntype ApplicationInfo struct {\n\tApplicationName string\n}\n"
+
+	opts := Options{
+		ExceptionsFiles: []string{"../exceptions.json"},
+		StaticDir:       "../static_include",
+		Overrides:       fstest.MapFS{"app_info.go": {Data: []byte(override)}},
+		Output:          NewMemFS(),
+		Targets:         []Target{{RegistryFile: "testdata/vk.xml", OutDir: "vulkan", Api: "vulkan"}},
+	}
+
+	if _, err := Generate(context.Background(), opts); err != nil {
+		t.Fatal(err)
+	}
+
+	data, found := opts.Output.(*MemFS).ReadFile("vulkan/struct.go")
+	if !found {
+		t.Fatal("vulkan/struct.go was not generated")
+	}
+	for _, text := range []string{"type ApplicationInfo struct", "func (s *ApplicationInfo) "} {
+		if strings.Contains(string(data), text) {
+			t.Errorf("struct.go contains %q", text)
+		}
+	}
+	if !strings.Contains(string(data), "func (s *InstanceCreateInfo) Vulkanize()") {
+		t.Error("struct.go is missing the methods of a type that was not replaced")
+	}
+}
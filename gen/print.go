@@ -0,0 +1,224 @@
+package gen
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/bbredesen/vk-gen/def"
+	"github.com/bbredesen/vk-gen/feat"
+)
+
+// fileHeader is stamped with the registry's header version and a hash of the inputs instead of the time, so that
+// generating twice from the same inputs gives identical files
+const fileHeader string = "// Code generated by go-vk from %s (VK_HEADER_VERSION %s, inputs sha256:%s). DO NOT EDIT.\n\npackage vk\n\n" // fix doc/issue-1
+
+func (g *generator) printCategory(tc def.TypeCategory, fc *feat.Feature, platform *feat.Platform, startingCount int) error {
+	if tc == def.CatInclude {
+		return nil
+	}
+
+	reg := fc.ResolvedTypes
+
+	if len(reg) == 0 && len(fc.ResolvedValues) == 0 {
+		return nil
+	}
+
+	filename := strings.ToLower(strings.TrimPrefix(tc.String(), "Cat"))
+	if platform != nil {
+		filename = filename + "_" + platform.Name()
+	}
+
+	f := &bytes.Buffer{}
+
+	if platform != nil && platform.GoBuildTag != "" && tc != def.CatEnum && tc != def.CatBitmask {
+		fmt.Fprintf(f, "//go:build %s\n", platform.GoBuildTag)
+	}
+
+	g.printFileHeader(f)
+
+	types := make([]def.TypeDefiner, 0, len(reg))
+	for k, v := range reg {
+		_ = k
+		types = append(types, v)
+		v.AppendValues(fc.ResolvedValues[v.RegistryName()])
+		delete(fc.ResolvedValues, v.RegistryName())
+	}
+
+	sort.Sort(def.ByName(types))
+
+	// Candidate imports; formatSource only keeps the ones the file uses
+	importMap := make(def.ImportMap)
+	for _, td := range types {
+		td.RegisterImports(importMap)
+	}
+	if platform != nil {
+		for _, i := range platform.GoImports {
+			importMap[i] = true
+		}
+	}
+
+	spans, err := g.printTypes(f, types, startingCount)
+	if err != nil {
+		return fmt.Errorf("%s.go: %w", filename, err)
+	}
+	printLooseValues(f, fc.ResolvedValues)
+
+	return g.writeSource(filename+".go", f.Bytes(), importMap, spans)
+}
+
+func (g *generator) printFileHeader(w io.Writer) {
+	fmt.Fprintf(w, fileHeader, filepath.Base(g.target.RegistryFile), g.headerVersion, g.inputHash)
+}
+
+// writeFile sends a file for the current target to the output filesystem. name is relative to the target's OutDir.
+func (g *generator) writeFile(name string, data []byte) error {
+	outpath := path.Join(filepath.ToSlash(g.target.OutDir), name)
+	if err := g.opts.Output.WriteFile(outpath, data); err != nil {
+		return fmt.Errorf("could not write %s: %w", outpath, err)
+	}
+	g.result.Files = append(g.result.Files, outpath)
+	g.written = append(g.written, path.Clean(name))
+	return nil
+}
+
+// writeSource formats a generated file and writes it. A file that fails to format is still written, unformatted, so
+// that it can be inspected, but the error is returned.
+func (g *generator) writeSource(name string, src []byte, candidates def.ImportMap, spans []typeSpan) error {
+	out, err := g.formatSource(name, src, candidates, spans)
+	if werr := g.writeFile(name, out); werr != nil {
+		return werr
+	}
+	return err
+}
+
+// printApiVersion writes the core version selected with -apiVersion into the generated package, so that consumers
+// can rely on (or check against) the core features that are present without enabling extensions.
+func (g *generator) printApiVersion(target *feat.Feature) error {
+	major, minor, _ := feat.ParseVersion(target.Version())
+	profile, _ := feat.ProfileForApi(g.target.Api) // Already validated when selecting features
+
+	f := &bytes.Buffer{}
+
+	g.printFileHeader(f)
+
+	fmt.Fprintf(f, "// TargetApiVersion is the %s core version (%s) this package was generated against. Core commands and\n", g.target.Api, target.Name())
+	fmt.Fprintf(f, "// types up to and including this version are available without enabling an extension.\n")
+	fmt.Fprintf(f, "var TargetApiVersion = makeApiVersion(%d, %d, %d, 0)\n", profile.Variant, major, minor)
+
+	return g.writeSource("version.go", f.Bytes(), nil, nil)
+}
+
+// printTypes writes the global consts, the file init() function and the declarations for types, either with the Go
+// printers in the def package or, if templates are enabled, by executing the template named for each type's category.
+// It returns where each type's code was written in f.
+func (g *generator) printTypes(f *bytes.Buffer, types []def.TypeDefiner, globalOffset int) ([]typeSpan, error) {
+	globalBuf := &strings.Builder{}
+	initBuf := &strings.Builder{}
+	contentBuf := &strings.Builder{}
+
+	// Offsets of each type's code in the three buffers, adjusted to offsets in f once the buffers are written
+	globalSpans, initSpans, contentSpans := make([]typeSpan, 0), make([]typeSpan, 0), make([]typeSpan, 0)
+	track := func(spans *[]typeSpan, buf *strings.Builder, name string, start int) {
+		if buf.Len() > start {
+			*spans = append(*spans, typeSpan{registryName: name, start: start, end: buf.Len()})
+		}
+	}
+
+	for i, v := range types {
+		if strings.HasPrefix(v.PublicName(), "!") {
+			continue
+		}
+		globalStart, initStart, contentStart := globalBuf.Len(), initBuf.Len(), contentBuf.Len()
+
+		if g.templates != nil {
+			data := def.NewTypeData(v, i+globalOffset, i == 0)
+			globalBuf.WriteString(data.Global)
+			initBuf.WriteString(data.Init)
+
+			if tmpl := g.templates.Lookup(data.Category); tmpl != nil {
+				if err := tmpl.Execute(contentBuf, data); err != nil {
+					return nil, fmt.Errorf("could not execute template for %s: %w", data.RegistryName, err)
+				}
+			} else {
+				contentBuf.WriteString(data.Public)
+				contentBuf.WriteString(data.Internal)
+			}
+		} else {
+			v.PrintGlobalDeclarations(globalBuf, i+globalOffset, i == 0)
+
+			v.PrintPublicDeclaration(contentBuf)
+			v.PrintInternalDeclaration(contentBuf)
+
+			v.PrintFileInitContent(initBuf) // Intentionally called after public declaration, which may do some processing needed for file init()
+		}
+
+		track(&globalSpans, globalBuf, v.RegistryName(), globalStart)
+		track(&initSpans, initBuf, v.RegistryName(), initStart)
+		track(&contentSpans, contentBuf, v.RegistryName(), contentStart)
+	}
+
+	rval := make([]typeSpan, 0, len(globalSpans)+len(initSpans)+len(contentSpans))
+	appendSpans := func(spans []typeSpan, base int) {
+		for _, s := range spans {
+			rval = append(rval, typeSpan{registryName: s.registryName, start: base + s.start, end: base + s.end})
+		}
+	}
+
+	if globalBuf.Len() > 0 {
+		fmt.Fprintf(f, "const (\n")
+		appendSpans(globalSpans, f.Len())
+		fmt.Fprint(f, globalBuf.String())
+		fmt.Fprintf(f, ")\n\n")
+	}
+
+	if initBuf.Len() > 0 {
+		fmt.Fprint(f, "func init() {\n")
+		appendSpans(initSpans, f.Len())
+		fmt.Fprint(f, initBuf.String())
+		fmt.Fprint(f, "}\n\n")
+	}
+
+	appendSpans(contentSpans, f.Len())
+	fmt.Fprint(f, contentBuf.String())
+	return rval, nil
+}
+
+func printLooseValues(w io.Writer, valsByTypeName map[string]def.ValueRegistry) {
+	// sort and refactored for cleanup/issue-3
+
+	typeNames := make([]string, 0, len(valsByTypeName))
+	for k := range valsByTypeName {
+		typeNames = append(typeNames, k)
+	}
+	sort.Strings(typeNames)
+
+	for _, k := range typeNames {
+		vr := valsByTypeName[k]
+		// Values will be sorted by const name for extension names/spec versions, and by value for typed consts
+		allValues := make([]def.ValueDefiner, 0, len(vr))
+		for _, val := range vr {
+			allValues = append(allValues, val)
+		}
+
+		if k == "" {
+			fmt.Fprint(w, "// Extension names and versions\n")
+			// Drop the values into a slice and sort by the const name
+			sort.Sort(def.ByValuePublicName(allValues))
+		} else {
+			fmt.Fprintf(w, "// Platform-specific values for %s\n", k)
+			sort.Sort(def.ByValue(allValues))
+		}
+
+		fmt.Fprintf(w, "const (\n")
+
+		for _, val := range allValues {
+			val.PrintPublicDeclaration(w)
+		}
+		fmt.Fprintf(w, ")\n\n")
+	}
+}
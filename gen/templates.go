@@ -0,0 +1,40 @@
+package gen
+
+import (
+	"embed"
+	"fmt"
+	"io/fs"
+	"strings"
+	"text/template"
+)
+
+// builtinTemplates reproduce the output of the Go printers in the def package. There is one template per category,
+// named for the category (e.g. "struct"), and each is executed with a def.TypeData.
+//
+//go:embed templates/*.tmpl
+var builtinTemplates embed.FS
+
+var templateFuncs = template.FuncMap{
+	"lower":      strings.ToLower,
+	"upper":      strings.ToUpper,
+	"trimPrefix": strings.TrimPrefix,
+	"trimSuffix": strings.TrimSuffix,
+	"join":       strings.Join,
+}
+
+// loadTemplates parses the built-in templates, followed by the *.tmpl files in each of dirs. A template defined in
+// a later directory replaces any earlier template with the same name, so a directory only needs to hold the
+// templates it changes.
+func loadTemplates(dirs []fs.FS) (*template.Template, error) {
+	rval, err := template.New("vk-gen").Funcs(templateFuncs).ParseFS(builtinTemplates, "templates/*.tmpl")
+	if err != nil {
+		return nil, fmt.Errorf("could not parse built-in templates: %w", err)
+	}
+
+	for i, dir := range dirs {
+		if rval, err = rval.ParseFS(dir, "*.tmpl"); err != nil {
+			return nil, fmt.Errorf("could not parse templates from directory %d: %w", i+1, err)
+		}
+	}
+	return rval, nil
+}
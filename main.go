@@ -1,39 +1,70 @@
 package main
 
 import (
+	"context"
+	"embed"
+	"errors"
 	"flag"
 	"fmt"
-	"go/build"
-	"io"
 	"io/fs"
-	"io/ioutil"
 	"os"
-	"os/exec"
-	"path/filepath"
-	"sort"
 	"strings"
-	"time"
 
-	"github.com/antchfx/xmlquery"
-	"github.com/bbredesen/vk-gen/def"
-	"github.com/bbredesen/vk-gen/feat"
+	"github.com/bbredesen/vk-gen/gen"
 	"github.com/sirupsen/logrus"
-	"github.com/tidwall/gjson"
+)
+
+// The default exceptions and static files are built into the binary, so that vk-gen can be run from any directory
+// (e.g., after go install). -exceptions and -staticDir override them with files on disk.
+var (
+	//go:embed exceptions.json
+	embeddedExceptions []byte
+
+	//go:embed static_include
+	embeddedStatic embed.FS
 )
 
 var (
 	inFileName, outDirName string
-	apiName                string
+	apiName, apiVersion    string
 	platformTargets        string
-	separatedPlatforms     []string
+	includeExtensions      string
+	excludeExtensions      string
+	targetSpecs            repeatedFlag
+	outputMode             string
+	exceptionsFileName     string
+	exceptionsOverlays     repeatedFlag
+	staticDirName          string
+	overridesDirName       string
+	strictExceptions       bool
 	useTemplates           bool
+	templateDirs           repeatedFlag
+	checkOnly              bool
 )
 
 func init() {
 	flag.StringVar(&inFileName, "inFile", "vk.xml", "Vulkan XML registry file to read")
 	flag.StringVar(&outDirName, "outDir", "vk", "Directory to write go-vk output to")
 	flag.StringVar(&apiName, "api", "vulkan", "API to generate against; possible values include 'vulkan' and 'vulkansc'")
+	flag.StringVar(&apiVersion, "apiVersion", "", "Core API version to generate against, e.g. '1.3'; all features of the selected API up to and including this version are included. Defaults to the latest version in the registry")
 	flag.StringVar(&platformTargets, "platform", "win32,macos,metal", "Comma-separated list of platforms to generate for; this looks at the Vulkan name, not the GOOS name for the platform")
+	flag.StringVar(&includeExtensions, "includeExtensions", "", "Comma-separated list of extension names (VK_KHR_swapchain) and/or vendor tags (KHR, EXT) to generate; if empty, all extensions are included. Dependencies of included extensions are always added")
+	flag.StringVar(&excludeExtensions, "excludeExtensions", "", "Comma-separated list of extension names and/or vendor tags (NV, AMDX) to leave out of the generated code, unless required by another included extension")
+	flag.Var(&targetSpecs, "target", "Generate an additional package, as semicolon-separated key=value pairs, e.g. 'api=vulkansc;outDir=vksc'. Keys are inFile, outDir, api, apiVersion, platform, includeExtensions and excludeExtensions; missing keys default to the matching flag. May be repeated. If any target is given, the top-level flags only provide defaults")
+
+	flag.StringVar(&outputMode, "output", "dir", "Where to write generated files: 'dir' writes to each -outDir, 'stdout' writes every file to stdout as one stream, and 'zip:<file>' or 'tar:<file>' write an archive")
+
+	flag.StringVar(&exceptionsFileName, "exceptions", "", "Exceptions file to use instead of the exceptions.json built into vk-gen")
+	flag.Var(&exceptionsOverlays, "exceptionsOverlay", "Exceptions file to apply on top of the base exceptions; entries are merged field by field and a null value removes an entry. May be repeated, and files are applied in order")
+	flag.StringVar(&overridesDirName, "overrides", "", "Directory of hand-written Go files to copy into the output; any top-level identifier declared there is omitted from the generated files")
+	flag.StringVar(&staticDirName, "staticDir", "", "Directory of static files to copy into the output instead of the static_include files built into vk-gen")
+
+	flag.BoolVar(&useTemplates, "useTemplates", false, "Render declarations from the built-in text/template templates instead of the Go printers; the output is the same")
+	flag.Var(&templateDirs, "templates", "Directory of *.tmpl files replacing built-in templates with the same name, e.g. {{define \"struct\"}}; implies -useTemplates. May be repeated, and later directories take precedence")
+
+	flag.BoolVar(&checkOnly, "check", false, "Generate in memory and compare the result with the files already in each output directory, without writing anything. Prints a unified diff and exits with status 1 if any file is missing, out of date, or would be removed as stale")
+
+	flag.BoolVar(&strictExceptions, "strict", false, "Fail if the exceptions do not match the expected schema, or if any exceptions entry does not match a registry element; otherwise these are reported as warnings")
 
 	flag.Parse()
 
@@ -41,345 +72,132 @@ func init() {
 }
 
 func main() {
+	defaults := gen.Target{
+		RegistryFile:      inFileName,
+		OutDir:            outDirName,
+		Api:               apiName,
+		ApiVersion:        apiVersion,
+		Platforms:         strings.Split(platformTargets, ","),
+		IncludeExtensions: strings.Split(includeExtensions, ","),
+		ExcludeExtensions: strings.Split(excludeExtensions, ","),
+	}
+
+	opts := gen.Options{
+		Targets:    []gen.Target{defaults},
+		Exceptions: [][]byte{embeddedExceptions},
+	}
+	if exceptionsFileName != "" {
+		opts.Exceptions = nil
+		opts.ExceptionsFiles = []string{exceptionsFileName}
+	}
+	opts.ExceptionsFiles = append(opts.ExceptionsFiles, exceptionsOverlays...)
+	opts.Strict = strictExceptions
+	opts.OverridesDir = overridesDirName
+	opts.UseTemplates = useTemplates
+	opts.TemplateDirs = templateDirs
+	if staticDirName != "" {
+		opts.StaticDir = staticDirName
+	} else {
+		opts.Static, _ = fs.Sub(embeddedStatic, "static_include") // Cannot fail, the directory is embedded
+	}
 
-	_, err := os.Stat(outDirName)
-	if err != nil {
-		if os.IsNotExist(err) {
-
-			if err := os.Mkdir(outDirName, 0777|fs.ModeDir); err != nil {
+	if len(targetSpecs) > 0 {
+		opts.Targets = opts.Targets[:0]
+		for _, spec := range targetSpecs {
+			t, err := parseTarget(spec, defaults)
+			if err != nil {
 				logrus.WithField("error", err).
-					Fatal("Could not create output directory")
-			} else {
-				logrus.WithField("directory", outDirName).
-					Info("Output directory created")
+					Fatal("Could not parse target")
 			}
+			opts.Targets = append(opts.Targets, t)
 		}
 	}
 
-	f, err := os.Open(inFileName)
-	if err != nil {
-		logrus.WithField("error", err).
-			WithField("filename", inFileName).
-			Fatal("Could not open Vulkan registry file")
-	}
-	defer f.Close()
-
-	separatedPlatforms = strings.Split(platformTargets, ",")
-	if len(separatedPlatforms) == 0 {
-		logrus.Info("Generating core Vulkan only; no platform specific extensions will be available!")
-	} else {
-		logrus.WithField("platforms", separatedPlatforms).Infof("Found %d platforms to generate for", len(separatedPlatforms))
-	}
-	xmlDoc, err := xmlquery.Parse(f)
-	if err != nil {
-		logrus.WithField("filename", inFileName).
-			WithField("error", err).
-			Fatal("Could not parse XML from the provided file")
+	if checkOnly {
+		check(opts)
+		return
 	}
 
-	exceptionsBytes, err := os.ReadFile("exceptions.json")
+	output, closeOutput, err := openOutput(outputMode)
 	if err != nil {
 		logrus.WithField("error", err).
-			Fatal("Could not parse json from exceptions.json")
+			Fatal("Could not open output")
 	}
+	opts.Output = output
 
-	jsonDoc := gjson.ParseBytes(exceptionsBytes)
-	_ = jsonDoc
-	globalTypes := make(def.TypeRegistry)
-	globalValues := make(def.ValueRegistry)
-
-	pm := def.ReadPlatformsFromXML(xmlDoc)
-	def.ReadPlatformExceptionsFromJSON(jsonDoc, pm)
-
-	for tc := def.CatNone; tc < def.CatMaximum; tc++ {
-		xml, json := tc.ReadFns()
-		if xml != nil {
-			xml(xmlDoc, globalTypes, globalValues, apiName)
-		}
-		if json != nil {
-			json(jsonDoc, globalTypes, globalValues)
-		}
-	}
-
-	platforms := make(feat.PlatformRegistry)
-	// static platform
-	platforms[""] = feat.NewGeneralPlatform()
-	for _, n := range xmlquery.Find(xmlDoc, "//platforms/platform") {
-		plat := feat.NewPlatformFromXML(n)
-		platforms[plat.Name()] = plat
+	_, err = gen.Generate(context.Background(), opts)
+	if closeErr := closeOutput(); err == nil {
+		err = closeErr
 	}
-	jsonDoc.Get("platform").ForEach(func(key, value gjson.Result) bool {
-		if key.String() == "!comment" {
-			return true
-		}
-		r := feat.NewOrUpdatePlatformFromJSON(key.String(), value, platforms[key.String()])
-		platforms[r.Name()] = r
-		return true
-	})
-
-	vk1_0 := feat.ReadFeatureFromXML(xmlquery.FindOne(xmlDoc, "//feature[@name='VK_VERSION_1_0']"), globalTypes, globalValues)
-	vk1_1 := feat.ReadFeatureFromXML(xmlquery.FindOne(xmlDoc, "//feature[@name='VK_VERSION_1_1']"), globalTypes, globalValues)
-	vk1_2 := feat.ReadFeatureFromXML(xmlquery.FindOne(xmlDoc, "//feature[@name='VK_VERSION_1_2']"), globalTypes, globalValues)
-	vk1_0.MergeWith(vk1_1)
-	vk1_0.MergeWith(vk1_2)
-
-	// Manually include external types
-	vk1_0.MergeIncludeSet(globalTypes.SelectCategory(def.CatExternal))
-
-	for _, platName := range separatedPlatforms {
-		xpath := fmt.Sprintf("//extension[@platform='%s']", platName)
-		for _, extNode := range xmlquery.Find(xmlDoc, xpath) {
-			ext := feat.ReadExtensionFromXML(extNode, globalTypes, globalValues)
-			platforms[ext.PlatformName()].IncludeExtension(ext)
-		}
-	}
-
-	// "Core" extensions
-	extQueryString := fmt.Sprintf("//extension[not(@platform) and contains(@supported,'%s')]", apiName)
-	for _, extNode := range xmlquery.Find(xmlDoc, extQueryString) {
-		ext := feat.ReadExtensionFromXML(extNode, globalTypes, globalValues)
-		platforms[""].IncludeExtension(ext)
-	}
-
-	vk1_0.MergeWith(platforms[""].GeneratePlatformFeatures())
-
-	vk1_0.Resolve(globalTypes, globalValues)
-
-	goimportsPath, err := findGoimports()
 	if err != nil {
-		logrus.
-			WithField("error", err.Error()).
-			Error("Could not find goimports")
-	}
-
-	commandCount := 0
-
-	for tc, reg := range vk1_0.FilterByCategory() {
-		if tc == def.CatHandle {
-			// Special case...VK_NULL_HANDLE is included by vk.xml as a type, not an enum. vk-gen treats it as a
-			// ValueDefiner, so it must be manually added to the feature registry.
-			globalValues["VK_NULL_HANDLE"].Resolve(globalTypes, globalValues)
-			reg.ResolvedTypes["VK_DEFINE_HANDLE"].PushValue(globalValues["VK_NULL_HANDLE"])
-		}
-
-		printCategory(tc, reg, nil, 0, goimportsPath)
-		if tc == def.CatCommand {
-			commandCount += len(reg.ResolvedTypes)
-		}
-
-	}
-
-	for pName, plat := range platforms {
-		if pName == "" {
-			continue
-		}
-
-		pf := plat.GeneratePlatformFeatures()
-		pf.Resolve(globalTypes, globalValues)
-
-		for tc, reg := range pf.FilterByCategory() {
-			printCategory(tc, reg, plat, commandCount, goimportsPath)
-			if tc == def.CatCommand {
-				commandCount += len(reg.ResolvedTypes)
-			}
-		}
+		logrus.WithField("error", err).
+			Fatal("Generation failed")
 	}
-
-	copyStaticFiles()
-
 }
 
-const fileHeader string = "// Code generated by go-vk from %s at %s. DO NOT EDIT.\n\npackage vk\n\n" // fix doc/issue-1
-
-func printCategory(tc def.TypeCategory, fc *feat.Feature, platform *feat.Platform, startingCount int, goimportsPath string) {
-	if tc == def.CatInclude {
-		return
+// check implements -check: it generates into memory and reports any difference from the files on disk
+func check(opts gen.Options) {
+	if outputMode != "dir" {
+		logrus.WithField("output", outputMode).
+			Fatal("-check compares against the output directories and cannot be combined with -output")
 	}
 
-	reg := fc.ResolvedTypes
+	mem := gen.NewMemFS()
+	opts.Output = mem
 
-	if len(reg) == 0 && len(fc.ResolvedValues) == 0 {
-		return
-	}
-
-	filename := strings.ToLower(strings.TrimPrefix(tc.String(), "Cat"))
-	if platform != nil {
-		filename = filename + "_" + platform.Name()
-	}
-
-	outpath := fmt.Sprintf("%s/%s", outDirName, filename+".go")
-
-	f, _ := os.Create(outpath)
-	// explicit f.Close() below; not deferred because the file must be written to disk before goimports is run
-
-	if platform != nil && platform.GoBuildTag != "" && tc != def.CatEnum && tc != def.CatBitmask {
-		fmt.Fprintf(f, "//go:build %s\n", platform.GoBuildTag)
-	}
-
-	fmt.Fprintf(f, fileHeader, inFileName, time.Now())
-
-	if platform != nil && len(platform.GoImports) > 0 {
-		fmt.Fprintf(f, "import (\n")
-		for _, i := range platform.GoImports {
-			fmt.Fprintf(f, "\"%s\"", i)
-		}
-		fmt.Fprintf(f, ")\n")
-	}
-
-	types := make([]def.TypeDefiner, 0, len(reg))
-	for k, v := range reg {
-		_ = k
-		types = append(types, v)
-		v.AppendValues(fc.ResolvedValues[v.RegistryName()])
-		delete(fc.ResolvedValues, v.RegistryName())
-	}
-
-	sort.Sort(def.ByName(types))
-	def.WriteStringerCommands(f, types, tc, filename)
-
-	importMap := make(def.ImportMap)
-	for _, t := range types {
-		t.RegisterImports(importMap)
-	}
-	if len(importMap) > 0 {
-		keys := importMap.SortedKeys()
-		fmt.Fprint(f, "import (\n")
-		for _, k := range keys {
-			fmt.Fprintf(f, "  \"%s\"\n", k)
-		}
-		fmt.Fprintln(f, ")")
-		fmt.Fprintln(f)
-	}
-
-	printTypes(f, types, fc.ResolvedValues, startingCount)
-	printLooseValues(f, fc.ResolvedValues)
-
-	f.Close()
-
-	logrus.WithField("file", filename+".go").Info("Running goimports")
-
-	cmd := exec.Command(goimportsPath, "-w", outpath)
-	e := &strings.Builder{}
-	cmd.Stderr = e
-
-	goimpErr := cmd.Run()
-	if goimpErr != nil {
-		logrus.
-			WithField("path", outpath).
-			WithField("error", goimpErr.Error()).
-			WithField("goimports output", e.String()).
-			Error("Failed to format source file")
-	}
-
-}
-
-func printTypes(w io.Writer, types []def.TypeDefiner, vals map[string]def.ValueRegistry, globalOffset int) {
-	globalBuf := &strings.Builder{}
-	initBuf := &strings.Builder{}
-	contentBuf := &strings.Builder{}
-
-	for i, v := range types {
-		if strings.HasPrefix(v.PublicName(), "!") {
-			continue
-		}
-
-		v.PrintGlobalDeclarations(globalBuf, i+globalOffset, i == 0)
-
-		v.PrintPublicDeclaration(contentBuf)
-		v.PrintInternalDeclaration(contentBuf)
-
-		v.PrintFileInitContent(initBuf) // Intentionally called after public declaration, which may do some processing needed for file init()
+	if _, err := gen.Generate(context.Background(), opts); err != nil {
+		logrus.WithField("error", err).
+			Fatal("Generation failed")
 	}
 
-	if globalBuf.Len() > 0 {
-		fmt.Fprintf(w, "const (\n")
-		fmt.Fprint(w, globalBuf.String())
-		fmt.Fprintf(w, ")\n\n")
+	diffs, err := gen.CompareOutput(mem, "")
+	if err != nil {
+		logrus.WithField("error", err).
+			Fatal("Could not compare output")
 	}
-
-	if initBuf.Len() > 0 {
-		fmt.Fprint(w, "func init() {\n")
-		fmt.Fprint(w, initBuf.String())
-		fmt.Fprint(w, "}\n\n")
+	if len(diffs) == 0 {
+		logrus.Info("Generated files are up to date")
+		return
 	}
 
-	fmt.Fprint(w, contentBuf.String())
-
-}
-
-func printLooseValues(w io.Writer, valsByTypeName map[string]def.ValueRegistry) {
-	// sort and refactored for cleanup/issue-3
-
-	for k, vr := range valsByTypeName {
-		// Values will be sorted by const name for extension names/spec versions, and by value for typed consts
-		allValues := make([]def.ValueDefiner, 0, len(vr))
-		for _, val := range vr {
-			allValues = append(allValues, val)
-		}
-
-		if k == "" {
-			fmt.Fprint(w, "// Extension names and versions\n")
-			// Drop the values into a slice and sort by the const name
-			sort.Sort(def.ByValuePublicName(allValues))
+	for _, d := range diffs {
+		fmt.Print(d.Diff)
+		if d.Missing {
+			logrus.WithField("file", d.Path).Error("Generated file is missing")
+		} else if d.Stale {
+			logrus.WithField("file", d.Path).Error("File is no longer generated and would be removed")
 		} else {
-			fmt.Fprintf(w, "// Platform-specific values for %s\n", k)
-			sort.Sort(def.ByValue(allValues))
+			logrus.WithField("file", d.Path).Error("Generated file is out of date")
 		}
-
-		fmt.Fprintf(w, "const (\n")
-
-		for _, val := range allValues {
-			val.PrintPublicDeclaration(w)
-		}
-		fmt.Fprintf(w, ")\n\n")
 	}
+	logrus.Errorf("%d generated file(s) differ; re-run vk-gen without -check to update them", len(diffs))
+	os.Exit(1)
 }
 
-func copyStaticFiles() {
-	logrus.Info("Copying static files")
-	source := "static_include"
+// openOutput returns the output filesystem selected with -output, and a function to finish writing to it
+func openOutput(mode string) (gen.OutputFS, func() error, error) {
+	kind, filename, _ := strings.Cut(mode, ":")
 
-	// Naive solution from https://stackoverflow.com/questions/51779243/copy-a-folder-in-go
-	var err error = filepath.Walk(source, func(path string, info os.FileInfo, err error) error {
-		var relPath string = strings.Replace(path, source, "", 1)
-		if relPath == "" {
-			return nil
+	switch kind {
+	case "dir":
+		return gen.DiskFS{}, func() error { return nil }, nil
+	case "stdout":
+		// Log output goes to stderr, so it does not mix with the generated files
+		return gen.ConcatFS{W: os.Stdout}, func() error { return nil }, nil
+	case "zip", "tar":
+		if filename == "" {
+			return nil, nil, fmt.Errorf("-output %s requires a filename, e.g. %s:vk.%s", kind, kind, kind)
 		}
-		if info.IsDir() {
-			return os.Mkdir(filepath.Join(outDirName, relPath), 0777)
-		} else {
-			var data, err1 = ioutil.ReadFile(filepath.Join(source, relPath))
-			if err1 != nil {
-				return err1
-			}
-			return ioutil.WriteFile(filepath.Join(outDirName, relPath), data, 0666)
+		f, err := os.Create(filename)
+		if err != nil {
+			return nil, nil, err
 		}
-	})
-
-	if err != nil {
-		panic(err)
-	}
-}
-
-func findGoimports() (path string, err error) {
-	// goimports is probably in GOPATH which may not be in the user's PATH
-	goPath := os.Getenv("GOPATH")
-	if goPath == "" {
-		// If GOPATH is not set, use go's default
-		goPath = build.Default.GOPATH
-	}
-
-	// There may be multiple paths, so split and add "/bin" to each
-	paths := strings.Split(goPath, string(os.PathListSeparator))
-	goPath = ""
-	for _, path := range paths {
-		goPath += fmt.Sprintf("%s%sbin%s", path, string(os.PathSeparator), string(os.PathListSeparator))
+		if kind == "zip" {
+			z := gen.NewZipFS(f)
+			return z, func() error { return errors.Join(z.Close(), f.Close()) }, nil
+		}
+		t := gen.NewTarFS(f)
+		return t, func() error { return errors.Join(t.Close(), f.Close()) }, nil
 	}
-
-	// Add PATH paths to the end
-	goPath += os.Getenv("PATH")
-
-	os.Setenv("PATH", goPath)
-
-	return exec.LookPath("goimports")
+	return nil, nil, fmt.Errorf("unknown output %q; expected dir, stdout, zip:<file> or tar:<file>", mode)
 }
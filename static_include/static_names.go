@@ -0,0 +1,91 @@
+package vk
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// flagName names one value of a bitmask type. The generated String method and Parse function for each bitmask are
+// built on a table of these, ordered by value and followed by aliases and any other names for the same values.
+type flagName struct {
+	value uint64
+	name  string
+}
+
+// formatFlags returns the names of the bits set in v, separated by "|". If v exactly matches a named value, such as
+// zero or SHADER_STAGE_ALL_GRAPHICS, that name is returned instead. Any set bits without a name are shown together in
+// hex at the end, e.g. "TRANSFER_DST_BIT|0x80000000".
+func formatFlags(v uint64, names []flagName) string {
+	for _, n := range names {
+		if n.value == v {
+			return n.name
+		}
+	}
+	if v == 0 {
+		return "0"
+	}
+
+	rval := make([]string, 0)
+	for _, n := range names {
+		if n.value != 0 && n.value&(n.value-1) == 0 && v&n.value != 0 {
+			rval = append(rval, n.name)
+			v &^= n.value
+		}
+	}
+	if v != 0 {
+		rval = append(rval, "0x"+strconv.FormatUint(v, 16))
+	}
+	return strings.Join(rval, "|")
+}
+
+// trimName removes surrounding space and the VK_ prefix from a value name, so that the registry name (e.g.
+// VK_FORMAT_R8G8B8A8_UNORM) is accepted as well as the Go constant name
+func trimName(s string) string {
+	return strings.TrimPrefix(strings.TrimSpace(s), "VK_")
+}
+
+// parseValue returns the value of an enum named s. Besides the name of the Go constant, s may be the registry name, or
+// either name without the prefix shared by all of the type's values: FORMAT_R8G8B8A8_UNORM,
+// VK_FORMAT_R8G8B8A8_UNORM, R8G8B8A8_UNORM and VK_R8G8B8A8_UNORM are all accepted by ParseFormat.
+func parseValue[T any](typeName, s, prefix string, values map[string]T) (T, error) {
+	name := trimName(s)
+	if v, found := values[name]; found {
+		return v, nil
+	}
+	if v, found := values[prefix+name]; found && prefix != "" {
+		return v, nil
+	}
+	var zero T
+	return zero, fmt.Errorf("%q is not a valid %s", s, typeName)
+}
+
+// parseFlags returns the bitmask named by s, the inverse of formatFlags. s holds any number of names separated by "|",
+// each accepted in the same forms as parseValue, or an integer such as 0 or 0x80000000. An integer must fit in
+// bitwidth bits, which is 32 or 64.
+func parseFlags(typeName, s, prefix string, bitwidth int, names []flagName) (uint64, error) {
+	var rval uint64
+	for _, part := range strings.Split(s, "|") {
+		name := trimName(part)
+		if n, err := strconv.ParseUint(name, 0, bitwidth); err == nil {
+			rval |= n
+			continue
+		} else if errors.Is(err, strconv.ErrRange) {
+			return 0, fmt.Errorf("%q is not a valid %s: %q does not fit in %d bits", s, typeName, strings.TrimSpace(part), bitwidth)
+		}
+
+		found := false
+		for _, n := range names {
+			if n.name == name || (prefix != "" && n.name == prefix+name) {
+				rval |= n.value
+				found = true
+				break
+			}
+		}
+		if !found {
+			return 0, fmt.Errorf("%q is not a valid %s: unknown flag %q", s, typeName, strings.TrimSpace(part))
+		}
+	}
+	return rval, nil
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/bbredesen/vk-gen/gen"
+)
+
+// repeatedFlag collects every use of a flag that may be given more than once, like -target
+type repeatedFlag []string
+
+func (t *repeatedFlag) String() string { return strings.Join(*t, " ") }
+func (t *repeatedFlag) Set(s string) error {
+	*t = append(*t, s)
+	return nil
+}
+
+// parseTarget reads a target specification of semicolon-separated key=value pairs, e.g.
+// "inFile=vk.xml;api=vulkansc;outDir=vksc;platform=win32,macos". Keys are named after the equivalent command line
+// flags, and any key that is not given takes its value from defaults.
+func parseTarget(spec string, defaults gen.Target) (gen.Target, error) {
+	rval := defaults
+
+	for _, pair := range strings.Split(spec, ";") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		key, value, found := strings.Cut(pair, "=")
+		if !found {
+			return rval, fmt.Errorf("target %q: expected key=value, found %q", spec, pair)
+		}
+
+		switch strings.TrimSpace(key) {
+		case "inFile":
+			rval.RegistryFile = value
+		case "outDir":
+			rval.OutDir = value
+		case "api":
+			rval.Api = value
+		case "apiVersion":
+			rval.ApiVersion = value
+		case "platform":
+			rval.Platforms = strings.Split(value, ",")
+		case "includeExtensions":
+			rval.IncludeExtensions = strings.Split(value, ",")
+		case "excludeExtensions":
+			rval.ExcludeExtensions = strings.Split(value, ",")
+		default:
+			return rval, fmt.Errorf("target %q: unknown key %q", spec, key)
+		}
+	}
+
+	return rval, nil
+}